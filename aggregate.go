@@ -0,0 +1,146 @@
+package goloquent
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+const (
+	aggregateCount = "COUNT"
+	aggregateSum   = "SUM"
+	aggregateAvg   = "AVG"
+	aggregateMin   = "MIN"
+	aggregateMax   = "MAX"
+)
+
+// softDeleteTables : cache of the tables without declared model, whether the table has soft delete column,
+// the entry is removed on migration
+var softDeleteTables sync.Map
+
+// hasSoftDelete : without model, the soft delete column is resolved from the declared model of the table,
+// otherwise from the columns of the table
+func (b *builder) hasSoftDelete(ctx context.Context, query scope, table string) bool {
+	if !query.onlyTrashed && query.noScope {
+		return false
+	}
+	if t := modelOf(table); t != nil {
+		if e, err := parseEntity(reflect.New(t).Interface()); err == nil {
+			return e.hasSoftDelete()
+		}
+	}
+	key := b.db.id + "/" + table
+	if x, isOk := softDeleteTables.Load(key); isOk {
+		return x.(bool)
+	}
+	cols := b.db.dialect.GetColumns(ctx, table)
+	isExist := false
+	for _, c := range cols {
+		if c == softDeleteColumn {
+			isExist = true
+			break
+		}
+	}
+	if len(cols) > 0 {
+		softDeleteTables.Store(key, isExist)
+	}
+	return isExist
+}
+
+// aggregateStmt : the orders, limit and offset of the query are ignored
+func (b *builder) aggregateStmt(ctx context.Context, fn, field string) (*stmt, error) {
+	query := b.query
+	if !query.noResolution {
+		query = query.append(extractResolution(ctx))
+	}
+	query.orders = nil
+	query.limit, query.offset = -1, -1
+
+	switch field {
+	case keyFieldName:
+		field = pkColumn
+	}
+
+	if b.hasSoftDelete(ctx, query, query.table) {
+		query = softDeleteScope(query)
+	}
+	query, err := b.applyGlobalScopes(ctx, query, query.table, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd, err := b.buildStmt(query)
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	buf.WriteString(fmt.Sprintf("SELECT %s FROM %s",
+		b.db.dialect.Aggregate(fn, field), b.db.dialect.GetTable(query.table)))
+	buf.WriteString(cmd.string())
+	buf.WriteString(";")
+	cmd.statement = buf
+	return cmd, nil
+}
+
+// aggregate : the result is zero when there is no record
+func (b *builder) aggregate(ctx context.Context, fn, field string) (float64, error) {
+	cmd, err := b.aggregateStmt(ctx, fn, field)
+	if err != nil {
+		return 0, err
+	}
+	var n sql.NullFloat64
+	if err := b.db.client.execQueryRow(ctx, cmd).Scan(&n); err != nil {
+		return 0, b.db.client.wrapError(err)
+	}
+	return n.Float64, nil
+}
+
+func (q *Query) aggregate(ctx context.Context, fn, field string) (float64, error) {
+	if err := q.getError(); err != nil {
+		return 0, err
+	}
+	if q.table == "" {
+		return 0, fmt.Errorf("goloquent: unable to perform aggregation without table name")
+	}
+	if len(q.joins) > 0 {
+		return 0, fmt.Errorf("goloquent: join query is not supported by aggregation")
+	}
+	if len(q.groupBy) > 0 || len(q.havings) > 0 {
+		return 0, fmt.Errorf("goloquent: grouped query is not supported by aggregation")
+	}
+	if fn != aggregateCount && field == "" {
+		return 0, fmt.Errorf("goloquent: missing field of %s", fn)
+	}
+	return newBuilder(q, operationRead).aggregate(ctx, fn, field)
+}
+
+// Count : count the records which match the query
+func (q *Query) Count(ctx context.Context) (int64, error) {
+	n, err := q.aggregate(ctx, aggregateCount, "")
+	return int64(n), err
+}
+
+// Sum : sum of the field, json path is supported, eg: `Address>PostCode`
+func (q *Query) Sum(ctx context.Context, field string) (float64, error) {
+	return q.aggregate(ctx, aggregateSum, field)
+}
+
+// Avg : average of the field
+func (q *Query) Avg(ctx context.Context, field string) (float64, error) {
+	return q.aggregate(ctx, aggregateAvg, field)
+}
+
+// Min : minimum value of the field, only numeric field is supported,
+// use `SelectAggregate(expr.Min(field))` with `Scan` for the others
+func (q *Query) Min(ctx context.Context, field string) (float64, error) {
+	return q.aggregate(ctx, aggregateMin, field)
+}
+
+// Max : maximum value of the field, only numeric field is supported,
+// use `SelectAggregate(expr.Max(field))` with `Scan` for the others
+func (q *Query) Max(ctx context.Context, field string) (float64, error) {
+	return q.aggregate(ctx, aggregateMax, field)
+}
@@ -21,6 +21,7 @@ import (
 const (
 	variable      = "??"
 	jsonDelimeter = ":"
+	maxBatchRows  = 1000
 )
 
 type index int
@@ -30,6 +31,19 @@ const (
 	uniqueIdx
 )
 
+// exprOperators : the operators which accept expression value, `EqualTo` is resolved by dialect
+var exprOperators = map[operator]string{
+	Equal:        "=",
+	EqualTo:      "",
+	NotEqual:     "<>",
+	GreaterThan:  ">",
+	GreaterEqual: ">=",
+	LessThan:     "<",
+	LessEqual:    "<=",
+	Like:         "LIKE",
+	NotLike:      "NOT LIKE",
+}
+
 type builder struct {
 	db    *DB
 	query scope
@@ -109,19 +123,77 @@ func (b *builder) dropTableIfExists(ctx context.Context, table string) error {
 }
 
 func (b *builder) quoteIfNecessary(v string) string {
-	if regexp.MustCompile("^[a-zA-Z\\d]+(\\.[a-zA-Z\\d]+)*$").MatchString(v) {
+	if regexp.MustCompile("^\\$?[a-zA-Z\\d]+(\\.[a-zA-Z\\d]+)*$").MatchString(v) {
 		return b.db.dialect.Quote(v)
 	}
 	return v
 }
 
+// buildAggregate : `*` of the aggregate is all the records
+func (b *builder) buildAggregate(a expr.Aggregate) string {
+	field := a.Field
+	switch field {
+	case "*":
+		field = ""
+	case keyFieldName:
+		field = pkColumn
+	}
+	return b.db.dialect.Aggregate(a.Func, field)
+}
+
+// buildGroupBy : the alias of aggregate in `Having` is rendered as the aggregate expression,
+// as alias is not allowed in the `HAVING` clause of postgres
+func (b *builder) buildGroupBy(query scope) (*stmt, error) {
+	buf, args := new(bytes.Buffer), make([]interface{}, 0)
+	if len(query.groupBy) > 0 {
+		fields := make([]string, len(query.groupBy))
+		for i, f := range query.groupBy {
+			if f == keyFieldName {
+				f = pkColumn
+			}
+			fields[i] = b.quoteIfNecessary(f)
+		}
+		buf.WriteString(" GROUP BY " + strings.Join(fields, ","))
+	}
+	if len(query.havings) > 0 {
+		aggregates := make(map[string]expr.Aggregate)
+		for _, p := range query.projection {
+			if x, isOk := p.(expr.Aggregate); isOk {
+				aggregates[x.Alias] = x
+			}
+		}
+		for _, x := range query.havingAggregates {
+			aggregates[x.Alias] = x
+		}
+		havings, vals, err := b.buildFilters(query.havings, func(f Filter) (string, bool) {
+			if x, isOk := aggregates[f.Field()]; isOk {
+				return b.buildAggregate(x), false
+			}
+			return b.db.dialect.Quote(f.Field()), false
+		})
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(" HAVING " + strings.Join(havings, " AND "))
+		args = append(args, vals...)
+	}
+	return &stmt{
+		statement: buf,
+		arguments: args,
+	}, nil
+}
+
 func (b *builder) buildSelect(query scope) *stmt {
 	scope := "*"
 	if len(query.projection) > 0 {
 		projection := make([]string, len(query.projection), len(query.projection))
-		copy(projection, query.projection)
-		for i := 0; i < len(query.projection); i++ {
-			projection[i] = b.quoteIfNecessary(projection[i])
+		for i, p := range query.projection {
+			switch vi := p.(type) {
+			case expr.Aggregate:
+				projection[i] = b.buildAggregate(vi) + " AS " + b.db.dialect.Quote(vi.Alias)
+			default:
+				projection[i] = b.quoteIfNecessary(fmt.Sprintf("%v", vi))
+			}
 		}
 		scope = strings.Join(projection, ",")
 	}
@@ -141,13 +213,58 @@ func (b *builder) buildSelect(query scope) *stmt {
 	}
 }
 
-func (b *builder) buildWhere(query scope) (*stmt, error) {
-	buf := new(bytes.Buffer)
+// buildFilters : the column of the filter is rendered by `column`, the value is converted to key string when it's key column
+func (b *builder) buildFilters(filters []Filter, column func(f Filter) (string, bool)) ([]string, []interface{}, error) {
 	wheres := make([]string, 0)
 	args := make([]interface{}, 0)
 
-	for _, f := range query.filters {
-		name := b.db.dialect.Quote(f.Field())
+	for _, f := range filters {
+		if f.IsGroup() {
+			if len(f.children) == 0 {
+				continue
+			}
+			children, vals, err := b.buildFilters(f.children, column)
+			if err != nil {
+				return nil, nil, err
+			}
+			sep := " AND "
+			if f.isAny {
+				sep = " OR "
+			}
+			wheres = append(wheres, "("+strings.Join(children, sep)+")")
+			args = append(args, vals...)
+			continue
+		}
+
+		name, isKey := column(f)
+		if f.IsGeo() {
+			str, vv, err := b.db.dialect.FilterGeo(f)
+			if err != nil {
+				return nil, nil, fmt.Errorf("goloquent: %w", err)
+			}
+			wheres = append(wheres, str)
+			args = append(args, vv...)
+			continue
+		}
+
+		// the value is rendered as expression, eg: `expr.Col("CreatedAt")`
+		if b.db.stmtRegistry().isExpr(f.value) {
+			op, isOk := exprOperators[f.operator]
+			if !isOk || f.IsJSON() {
+				return nil, nil, fmt.Errorf("goloquent: expression value is not supported by the filter of %q", f.Field())
+			}
+			if f.operator == EqualTo {
+				op = b.db.dialect.NullSafeEqual()
+			}
+			buf := new(bytes.Buffer)
+			vals, err := b.db.stmtRegistry().BuildStatement(buf, reflect.ValueOf(f.value))
+			if err != nil {
+				return nil, nil, fmt.Errorf("goloquent: %w", err)
+			}
+			wheres = append(wheres, fmt.Sprintf("%s %s %s", name, op, buf.String()))
+			args = append(args, vals...)
+			continue
+		}
 
 		var v interface{}
 		switch vi := f.value.(type) {
@@ -159,7 +276,7 @@ func (b *builder) buildWhere(query scope) (*stmt, error) {
 			subQuery.WriteString(b.db.dialect.GetTable(vi.scope.table))
 			stmt, err := b.buildStmt(vi.scope)
 			if err != nil {
-				return nil, fmt.Errorf("goloquent: %v", err)
+				return nil, nil, fmt.Errorf("goloquent: %v", err)
 			}
 			subQuery.WriteString(stmt.string())
 			subQuery.WriteString(")")
@@ -169,25 +286,23 @@ func (b *builder) buildWhere(query scope) (*stmt, error) {
 		default:
 			vi, err := f.Interface()
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 
 			if f.IsJSON() {
 				str, vv, err := b.db.dialect.FilterJSON(f)
 				if err != nil {
-					return nil, fmt.Errorf("goloquent: %w", err)
+					return nil, nil, fmt.Errorf("goloquent: %w", err)
 				}
 				wheres = append(wheres, str)
 				args = append(args, vv...)
 				continue
 			}
 
-			switch f.Field() {
-			case keyFieldName, pkColumn:
-				name = b.db.dialect.Quote(pkColumn)
+			if isKey {
 				vi, err = interfaceToKeyString(f.value)
 				if err != nil {
-					return nil, err
+					return nil, nil, err
 				}
 			}
 			v = vi
@@ -201,7 +316,7 @@ func (b *builder) buildWhere(query scope) (*stmt, error) {
 				continue
 			}
 		case EqualTo:
-			op = "<=>"
+			op = b.db.dialect.NullSafeEqual()
 		case NotEqual:
 			op = "<>"
 			if v == nil {
@@ -222,7 +337,7 @@ func (b *builder) buildWhere(query scope) (*stmt, error) {
 				x = append(x, v)
 			}
 			if len(x) <= 0 {
-				return nil, fmt.Errorf(`goloquent: value for "AnyLike" operator cannot be empty`)
+				return nil, nil, fmt.Errorf(`goloquent: value for "AnyLike" operator cannot be empty`)
 			}
 			buf := new(bytes.Buffer)
 			buf.WriteByte('(')
@@ -248,7 +363,7 @@ func (b *builder) buildWhere(query scope) (*stmt, error) {
 					x = append(x, v)
 				}
 				if len(x) <= 0 {
-					return nil, fmt.Errorf(`goloquent: value for "In" operator cannot be empty`)
+					return nil, nil, fmt.Errorf(`goloquent: value for "In" operator cannot be empty`)
 				}
 				vv = fmt.Sprintf("(%s)", strings.TrimRight(
 					strings.Repeat(variable+",", len(x)), ","))
@@ -269,7 +384,7 @@ func (b *builder) buildWhere(query scope) (*stmt, error) {
 					x = append(x, v)
 				}
 				if len(x) <= 0 {
-					return nil, fmt.Errorf(`goloquent: value for "NotIn" operator cannot be empty`)
+					return nil, nil, fmt.Errorf(`goloquent: value for "NotIn" operator cannot be empty`)
 				}
 				vv = fmt.Sprintf("(%s)", strings.TrimRight(
 					strings.Repeat(variable+",", len(x)), ","))
@@ -299,13 +414,40 @@ func (b *builder) buildWhere(query scope) (*stmt, error) {
 		wheres = append(wheres, fmt.Sprintf("%s %s %s", name, op, vv))
 		args = append(args, v)
 	}
+	return wheres, args, nil
+}
+
+func (b *builder) buildWhere(query scope) (*stmt, error) {
+	buf := new(bytes.Buffer)
+	filters := query.filters
+	if len(query.joins) > 0 {
+		filters = qualifyFilters(query, query.table, filters)
+	}
+	wheres, args, err := b.buildFilters(filters, func(f Filter) (string, bool) {
+		if len(query.joins) > 0 {
+			_, name, isKey := b.joinColumn(query, query.table, f.Field())
+			return name, isKey
+		}
+		switch f.Field() {
+		case keyFieldName, pkColumn:
+			return b.db.dialect.Quote(pkColumn), true
+		}
+		return b.db.dialect.Quote(f.Field()), false
+	})
+	if err != nil {
+		return nil, err
+	}
 
+	pk := b.db.dialect.Quote(pkColumn)
+	if len(query.joins) > 0 {
+		_, pk, _ = b.joinColumn(query, query.table, pkColumn)
+	}
 	for _, aa := range query.ancestors {
 		if aa.isGroup {
 			buf := new(bytes.Buffer)
 			buf.WriteByte('(')
 			for _, x := range aa.data {
-				buf.WriteString(fmt.Sprintf("%s LIKE %s OR ", b.db.dialect.Quote(pkColumn), variable))
+				buf.WriteString(fmt.Sprintf("%s LIKE %s OR ", pk, variable))
 				args = append(args, fmt.Sprintf("%%%s/%%", stringifyKey(x.(*datastore.Key))))
 			}
 			buf.Truncate(buf.Len() - 4)
@@ -314,7 +456,7 @@ func (b *builder) buildWhere(query scope) (*stmt, error) {
 			continue
 		}
 
-		wheres = append(wheres, b.db.dialect.Quote(pkColumn)+" LIKE "+variable)
+		wheres = append(wheres, pk+" LIKE "+variable)
 		args = append(args, fmt.Sprintf("%%%s/%%", stringifyKey(aa.data[0].(*datastore.Key))))
 	}
 
@@ -343,7 +485,25 @@ func (b *builder) buildOrderBy(query scope) (*stmt, error) {
 			if i > 0 {
 				buf.WriteByte(',')
 			}
-			vals, err := stmtRegistry.BuildStatement(buf, reflect.ValueOf(o))
+			if x, isOk := o.(geoSort); isOk {
+				str, vals := b.db.dialect.GeoDistance(x.field, x.point)
+				buf.WriteString(str)
+				if x.direction == expr.Descending {
+					buf.WriteString(" DESC")
+				}
+				args = append(args, vals...)
+				continue
+			}
+			// the sorting column of join query is qualified by table
+			if x, isOk := o.(expr.Sort); isOk && len(query.joins) > 0 {
+				_, name, _ := b.joinColumn(query, query.table, x.Name)
+				buf.WriteString(name)
+				if x.Direction == expr.Descending {
+					buf.WriteString(" DESC")
+				}
+				continue
+			}
+			vals, err := b.db.stmtRegistry().BuildStatement(buf, reflect.ValueOf(o))
 			if err != nil {
 				return nil, err
 			}
@@ -380,6 +540,12 @@ func (b *builder) buildStmt(query scope, args ...interface{}) (*stmt, error) {
 		args = append(args, cmd.arguments...)
 		buf.WriteString(cmd.string())
 	}
+	gs, err := b.buildGroupBy(query)
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteString(gs.string())
+	args = append(args, gs.arguments...)
 	ss, err := b.buildOrderBy(query)
 	if err != nil {
 		return nil, err
@@ -397,25 +563,29 @@ func (b *builder) createTable(ctx context.Context, e *entity) error {
 	return b.db.dialect.CreateTable(ctx, e.Name(), e.columns)
 }
 
-func (b *builder) alterTable(ctx context.Context, e *entity) error {
-	return b.db.dialect.AlterTable(ctx, e.Name(), e.columns, false)
+func (b *builder) alterTable(ctx context.Context, e *entity, opts MigrateOptions) error {
+	return b.db.dialect.AlterTable(ctx, e.Name(), e.columns, opts)
 }
 
-func (b *builder) migrate(ctx context.Context, model interface{}) error {
+func (b *builder) migrate(ctx context.Context, model interface{}, opts MigrateOptions) error {
 	e, err := newEntity(model)
 	if err != nil {
 		return err
 	}
 	e.setName(b.query.table)
+	softDeleteTables.Delete(b.db.id + "/" + e.Name())
 	if b.db.dialect.HasTable(ctx, e.Name()) {
-		return b.alterTable(ctx, e)
+		return b.alterTable(ctx, e, opts)
 	}
 	return b.createTable(ctx, e)
 }
 
-func (b *builder) migrateMultiple(ctx context.Context, models []interface{}) error {
+func (b *builder) migrateMultiple(ctx context.Context, models []interface{}, opts MigrateOptions) error {
+	if err := checkMigrateModels(models); err != nil {
+		return err
+	}
 	for _, m := range models {
-		if err := b.migrate(ctx, m); err != nil {
+		if err := b.migrate(ctx, m, opts); err != nil {
 			return err
 		}
 	}
@@ -432,24 +602,25 @@ func (b *builder) getCommand(ctx context.Context, e *entity) (*stmt, error) {
 	buf := new(bytes.Buffer)
 	buf.WriteString(b.buildSelect(query).string())
 	buf.WriteString(" FROM " + b.db.dialect.GetTable(e.Name()))
-	if !query.noScope && e.hasSoftDelete() {
-		query.filters = append(query.filters, Filter{
-			field:    softDeleteColumn,
-			operator: Equal,
-			value:    nil,
-		})
+	// the model of aggregate query is not the table, eg: the report of `GroupBy`
+	if e.hasSoftDelete() || (query.isAggregate() && b.hasSoftDelete(ctx, query, e.Name())) {
+		query = softDeleteScope(query)
+	}
+	t := e.typeOf
+	if query.isAggregate() {
+		// the scopes are resolved from the declared model of the table
+		t = nil
+	}
+	query, err := b.applyGlobalScopes(ctx, query, e.Name(), t)
+	if err != nil {
+		return nil, err
 	}
 	cmd, err := b.buildStmt(query)
 	if err != nil {
 		return nil, err
 	}
 	buf.WriteString(cmd.string())
-	switch query.lockMode {
-	case ReadLock:
-		buf.WriteString(" LOCK IN SHARE MODE")
-	case WriteLock:
-		buf.WriteString(" FOR UPDATE")
-	}
+	buf.WriteString(b.db.dialect.LockMode(query.lockMode))
 	buf.WriteString(";")
 
 	return &stmt{
@@ -461,7 +632,7 @@ func (b *builder) getCommand(ctx context.Context, e *entity) (*stmt, error) {
 func (b *builder) run(ctx context.Context, table string, cmd *stmt) (*Iterator, error) {
 	var rows, err = b.db.client.execQuery(ctx, cmd)
 	if err != nil {
-		return nil, fmt.Errorf("goloquent: %v", err)
+		return nil, fmt.Errorf("goloquent: %w", err)
 	}
 	defer rows.Close()
 	cols, err := rows.Columns()
@@ -478,27 +649,56 @@ func (b *builder) run(ctx context.Context, table string, cmd *stmt) (*Iterator,
 
 	i := 0
 	for rows.Next() {
-		m := make([]interface{}, len(cols))
-		for j := range cols {
-			m[j] = &m[j]
-		}
-
-		if err := rows.Scan(m...); err != nil {
+		if err := it.read(rows, i); err != nil {
 			return nil, err
 		}
-
-		for j, name := range cols {
-			it.put(i, name, m[j])
-		}
-		it.patchKey()
 		i++
 	}
 
 	return &it, nil
 }
 
-func (b *builder) get(ctx context.Context, model interface{}, mustExist bool) error {
+// rows : execute the query and return the cursor without reading the result
+func (b *builder) rows(ctx context.Context, model interface{}) (*Rows, error) {
 	e, err := newEntity(model)
+	if err != nil {
+		return nil, err
+	}
+	e.setName(b.query.table)
+	cmd, err := b.getCommand(ctx, e)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := b.db.client.execQuery(ctx, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("goloquent: %w", err)
+	}
+	cols, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("goloquent: %v", err)
+	}
+	return &Rows{
+		rows: rows,
+		it: &Iterator{
+			table:    e.Name(),
+			stmt:     &Stmt{stmt: *cmd, replacer: b.db.dialect},
+			position: -1,
+			columns:  cols,
+		},
+	}, nil
+}
+
+// readEntity : the model of aggregate query is not required to have primary key, eg: the report of `GroupBy`
+func (b *builder) readEntity(model interface{}) (*entity, error) {
+	if b.query.isAggregate() {
+		return parseEntity(model)
+	}
+	return newEntity(model)
+}
+
+func (b *builder) get(ctx context.Context, model interface{}, mustExist bool) error {
+	e, err := b.readEntity(model)
 	if err != nil {
 		return err
 	}
@@ -532,7 +732,7 @@ func (b *builder) get(ctx context.Context, model interface{}, mustExist bool) er
 }
 
 func (b *builder) getMulti(ctx context.Context, model interface{}) error {
-	e, err := newEntity(model)
+	e, err := b.readEntity(model)
 	if err != nil {
 		return err
 	}
@@ -604,12 +804,12 @@ func (b *builder) paginate(ctx context.Context, p *Pagination, model interface{}
 		buf, args := new(bytes.Buffer), make([]interface{}, 0)
 		buf.WriteString(b.buildSelect(query).string())
 		buf.WriteString(fmt.Sprintf(" FROM %s", b.db.dialect.GetTable(e.Name())))
-		if !query.noScope && e.hasSoftDelete() {
-			query.filters = append(query.filters, Filter{
-				field:    softDeleteColumn,
-				operator: Equal,
-				value:    nil,
-			})
+		if e.hasSoftDelete() {
+			query = softDeleteScope(query)
+		}
+		query, err = b.applyGlobalScopes(ctx, query, e.Name(), e.typeOf)
+		if err != nil {
+			return err
 		}
 		cmd, err := b.buildWhere(query)
 		if err != nil {
@@ -765,11 +965,11 @@ func (b *builder) insertInto(ctx context.Context, table string) error {
 	})
 }
 
-func (b *builder) putStmt(ctx context.Context, parentKey []*datastore.Key, e *entity) (*stmt, error) {
+// putValues : the column values of every entity, the updated timestamp is always set when `isUpsert`
+func (b *builder) putValues(ctx context.Context, parentKey []*datastore.Key, e *entity, isUpsert bool) ([][]interface{}, error) {
 	v := e.slice.Elem()
 
 	isInline := (parentKey == nil && len(parentKey) == 0)
-	buf, args := new(bytes.Buffer), make([]interface{}, 0)
 	keys := make([]*datastore.Key, v.Len(), v.Len())
 	if !isInline {
 		for i := 0; i < len(keys); i++ {
@@ -778,10 +978,7 @@ func (b *builder) putStmt(ctx context.Context, parentKey []*datastore.Key, e *en
 	}
 
 	cols := e.Columns()
-	buf.WriteString(fmt.Sprintf("INSERT INTO %s (%s) VALUES ",
-		b.db.dialect.GetTable(e.Name()),
-		b.db.dialect.Quote(strings.Join(e.Columns(), b.db.dialect.Quote(",")))))
-
+	rows := make([][]interface{}, 0, v.Len())
 	for i := 0; i < v.Len(); i++ {
 		f := reflect.Indirect(v.Index(i))
 		if !f.IsValid() {
@@ -805,21 +1002,22 @@ func (b *builder) putStmt(ctx context.Context, parentKey []*datastore.Key, e *en
 		}
 		fv.Set(reflect.ValueOf(pk))
 
+		if err := runHook(ctx, b.db, vi.Interface(), hookBeforeCreate); err != nil {
+			return nil, err
+		}
 		if x, isOk := vi.Interface().(Saver); isOk {
 			if err := x.Save(ctx); err != nil {
 				return nil, err
 			}
 		}
+		touchTimestamps(vi, e.codec, true, isUpsert)
 		props, err := SaveStruct(vi.Interface())
 		if err != nil {
-			return nil, nil
+			return nil, err
 		}
 
 		props[pkColumn] = Property{[]string{pkColumn}, typeOfPtrKey, stringPk(pk)}
 		f.Set(vi.Elem())
-		if i != 0 {
-			buf.WriteString(",")
-		}
 		vals := make([]interface{}, len(cols), len(cols))
 		for j, c := range cols {
 			vv, err := props[c].Interface()
@@ -828,21 +1026,71 @@ func (b *builder) putStmt(ctx context.Context, parentKey []*datastore.Key, e *en
 			}
 			vals[j] = vv
 		}
+		rows = append(rows, vals)
+	}
+	return rows, nil
+}
 
-		buf.WriteString("(")
-		for j := 1; j <= len(cols); j++ {
-			buf.WriteString(variable + ",")
+// batchSize : the number of rows of each insert statement, limited by `maxBatchRows` and
+// the bind parameters limit of the dialect
+func batchSize(numOfCols, maxParams int) int {
+	size := maxBatchRows
+	if numOfCols > 0 && maxParams > 0 && maxParams/numOfCols < size {
+		size = maxParams / numOfCols
+	}
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// putStmts : the rows are split into multiple insert statements by batch size, the statements end without ";"
+func (b *builder) putStmts(e *entity, rows [][]interface{}) []*stmt {
+	cols := e.Columns()
+	size := batchSize(len(cols), b.db.dialect.MaxBindParameters())
+	cmds := make([]*stmt, 0, (len(rows)+size-1)/size)
+	for i := 0; i < len(rows); i += size {
+		j := i + size
+		if j > len(rows) {
+			j = len(rows)
 		}
-		buf.Truncate(buf.Len() - 1)
-		buf.WriteString(")")
-		args = append(args, vals...)
+		buf, args := new(bytes.Buffer), make([]interface{}, 0, (j-i)*len(cols))
+		buf.WriteString(fmt.Sprintf("INSERT INTO %s (%s) VALUES ",
+			b.db.dialect.GetTable(e.Name()),
+			b.db.dialect.Quote(strings.Join(cols, b.db.dialect.Quote(",")))))
+		for k, vals := range rows[i:j] {
+			if k != 0 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("(")
+			for n := 1; n <= len(cols); n++ {
+				buf.WriteString(variable + ",")
+			}
+			buf.Truncate(buf.Len() - 1)
+			buf.WriteString(")")
+			args = append(args, vals...)
+		}
+		cmds = append(cmds, &stmt{
+			statement: buf,
+			arguments: args,
+		})
 	}
-	buf.WriteString(";")
+	return cmds
+}
 
-	return &stmt{
-		statement: buf,
-		arguments: args,
-	}, nil
+// execBatch : the statements are executed in a transaction when there is more than one batch
+func (b *builder) execBatch(ctx context.Context, cmds []*stmt) error {
+	if len(cmds) == 1 {
+		return b.db.client.execStmt(ctx, cmds[0])
+	}
+	return b.db.RunInTransactionContext(ctx, nil, func(txn *DB) error {
+		for _, cmd := range cmds {
+			if err := txn.client.execStmt(ctx, cmd); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func (b *builder) put(ctx context.Context, model interface{}, parentKey []*datastore.Key) error {
@@ -854,11 +1102,23 @@ func (b *builder) put(ctx context.Context, model interface{}, parentKey []*datas
 	if e.slice.Elem().Len() <= 0 {
 		return nil
 	}
-	cmd, err := b.putStmt(ctx, parentKey, e)
+	rows, err := b.putValues(ctx, parentKey, e, false)
 	if err != nil {
 		return err
 	}
-	return b.db.client.execStmt(ctx, cmd)
+	if b.db.client.copyFrom && b.db.dialect.CopyFromStmt(e.Name(), e.Columns()) != "" {
+		err = b.copyFrom(ctx, e, rows)
+	} else {
+		cmds := b.putStmts(e, rows)
+		for _, cmd := range cmds {
+			cmd.statement.WriteString(";")
+		}
+		err = b.execBatch(ctx, cmds)
+	}
+	if err != nil {
+		return err
+	}
+	return runHooks(ctx, b.db, e.slice, hookAfterCreate)
 }
 
 func (b *builder) upsert(ctx context.Context, model interface{}, parentKey []*datastore.Key) error {
@@ -870,12 +1130,16 @@ func (b *builder) upsert(ctx context.Context, model interface{}, parentKey []*da
 	if e.slice.Elem().Len() <= 0 {
 		return nil
 	}
-	cmd, err := b.putStmt(ctx, parentKey, e)
+	rows, err := b.putValues(ctx, parentKey, e, true)
 	if err != nil {
 		return err
 	}
 	cols := e.Columns()
 	omits := newDictionary(b.query.omits)
+	// created timestamp should remain unchanged on conflict
+	if created, _ := e.codec.timestampFields(); created != nil {
+		omits.add(created.name)
+	}
 	columns := make([]string, 0, len(cols))
 	for _, c := range cols {
 		if omits.has(c) || c == pkColumn || c == keyFieldName {
@@ -883,72 +1147,102 @@ func (b *builder) upsert(ctx context.Context, model interface{}, parentKey []*da
 		}
 		columns = append(columns, c)
 	}
-	cmd.statement.Truncate(cmd.statement.Len() - 1)
-	buf := new(bytes.Buffer)
-	buf.WriteString(cmd.string())
-	if len(columns) > 0 {
-		buf.WriteString(" " + b.db.dialect.OnConflictUpdate(e.Name(), columns))
+	cmds := b.putStmts(e, rows)
+	for _, cmd := range cmds {
+		if len(columns) > 0 {
+			cmd.statement.WriteString(" " + b.db.dialect.OnConflictUpdate(e.Name(), columns))
+		}
+		cmd.statement.WriteString(";")
 	}
-	buf.WriteString(";")
-	cmd.statement = buf
-	return b.db.client.execStmt(ctx, cmd)
+	if err := b.execBatch(ctx, cmds); err != nil {
+		return err
+	}
+	return runHooks(ctx, b.db, e.slice, hookAfterCreate)
 }
 
-func (b *builder) saveMutation(ctx context.Context, model interface{}) (*stmt, error) {
+// saveMutation : the version field and the unversioned query of the record are return when the entity is versioned
+func (b *builder) saveMutation(ctx context.Context, model interface{}) (*stmt, *field, scope, error) {
 	v := reflect.Indirect(reflect.ValueOf(model))
 	if v.Len() <= 0 {
-		return new(stmt), nil
+		return new(stmt), nil, scope{}, nil
 	}
 	e, err := newEntity(model)
 	if err != nil {
-		return nil, err
+		return nil, nil, scope{}, err
 	}
 	e.setName(b.query.table)
 	buf := new(bytes.Buffer)
 	args := make([]interface{}, 0)
 	buf.WriteString(fmt.Sprintf("UPDATE %s SET ", b.db.dialect.GetTable(e.Name())))
 	f := v.Index(0)
+	if err := runHook(ctx, b.db, f.Interface(), hookBeforeUpdate); err != nil {
+		return nil, nil, scope{}, err
+	}
 	if x, isOk := f.Interface().(Saver); isOk {
 		if err := x.Save(ctx); err != nil {
-			return nil, err
+			return nil, nil, scope{}, err
 		}
 	}
+	codec, err := getStructCodec(f.Interface())
+	if err != nil {
+		return nil, nil, scope{}, err
+	}
+	touchTimestamps(f, codec, false, true)
 	props, err := SaveStruct(f.Interface())
 	if err != nil {
-		return nil, err
+		return nil, nil, scope{}, err
 	}
+	vf := codec.versionField()
 
 	pk, isOk := props[keyFieldName].Value.(*datastore.Key)
 	if !isOk {
-		return nil, fmt.Errorf("goloquent: entity %q has no primary key property", f.Type().Name())
+		return nil, nil, scope{}, fmt.Errorf("goloquent: entity %q has no primary key property", f.Type().Name())
 	}
 	delete(props, keyFieldName)
 	if pk == nil || pk.Incomplete() {
-		return nil, fmt.Errorf("goloquent: invalid key value, %v", pk)
+		return nil, nil, scope{}, fmt.Errorf("goloquent: invalid key value, %v", pk)
 	}
 
 	omits := newDictionary(b.query.omits)
 	j := int(1)
 	for k, p := range props {
-		if omits.has(k) {
+		if omits.has(k) || (vf != nil && k == vf.name) {
 			continue
 		}
 		it, err := p.Interface()
 		if err != nil {
-			return nil, err
+			return nil, nil, scope{}, err
 		}
 		buf.WriteString(fmt.Sprintf("%s = %s,", b.db.dialect.Quote(k), variable))
 		args = append(args, it)
 		j++
 	}
+	version := int64(0)
+	if vf != nil {
+		version = getVersion(getFieldByIndex(reflect.Indirect(f), vf.paths))
+		buf.WriteString(fmt.Sprintf("%s = %s,", b.db.dialect.Quote(vf.name), variable))
+		args = append(args, version+1)
+	}
 	buf.Truncate(buf.Len() - 1)
-	buf.WriteString(fmt.Sprintf(" WHERE %s = %s LIMIT 1;", b.db.dialect.Quote(pkColumn), variable))
+	buf.WriteString(fmt.Sprintf(" WHERE %s = %s", b.db.dialect.Quote(pkColumn), variable))
 	args = append(args, stringPk(pk))
+	if vf != nil {
+		buf.WriteString(fmt.Sprintf(" AND %s = %s", b.db.dialect.Quote(vf.name), variable))
+		args = append(args, version)
+	}
+	if b.db.dialect.UpdateWithLimit() {
+		buf.WriteString(" LIMIT 1")
+	}
+	buf.WriteString(";")
 
+	unversioned := scope{
+		table:   e.Name(),
+		filters: []Filter{{field: keyFieldName, operator: Equal, value: pk}},
+	}
 	return &stmt{
 		statement: buf,
 		arguments: args,
-	}, nil
+	}, vf, unversioned, nil
 }
 
 func (b *builder) save(ctx context.Context, model interface{}) error {
@@ -960,20 +1254,37 @@ func (b *builder) save(ctx context.Context, model interface{}) error {
 	vi.Index(0).Set(v)
 	vv := reflect.New(vi.Type())
 	vv.Elem().Set(vi)
-	cmd, err := b.saveMutation(ctx, vv.Interface())
+	cmd, vf, unversioned, err := b.saveMutation(ctx, vv.Interface())
 	if err != nil {
 		return err
 	}
-	if err := b.db.client.execStmt(ctx, cmd); err != nil {
+	result, err := b.db.client.execResult(ctx, cmd)
+	if err != nil {
 		return err
 	}
+	if vf != nil {
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n <= 0 {
+			return b.checkStale(ctx, unversioned.table, unversioned, ErrNoSuchEntity)
+		}
+		fv := getFieldByIndex(vi.Index(0).Elem(), vf.paths)
+		setVersion(fv, getVersion(fv)+1)
+	}
 	v.Elem().Set(vi.Index(0).Elem())
-	return nil
+	return runHook(ctx, b.db, model, hookAfterUpdate)
 }
 
-func (b *builder) updateWithMap(v reflect.Value) (*stmt, error) {
+// updateWithMap : the updated timestamp of the declared model is set when it's not in the map
+func (b *builder) updateWithMap(table string, v reflect.Value) (*stmt, error) {
 	buf := new(bytes.Buffer)
 	args := make([]interface{}, 0)
+	v, err := touchMapTimestamp(table, v)
+	if err != nil {
+		return nil, err
+	}
 	for _, k := range v.MapKeys() {
 		vv := v.MapIndex(k)
 		if k.Kind() != reflect.String {
@@ -983,6 +1294,17 @@ func (b *builder) updateWithMap(v reflect.Value) (*stmt, error) {
 		if kk == keyFieldName {
 			return nil, fmt.Errorf("goloquent: update __key__ is not allow")
 		}
+		// the value is rendered as expression, eg: `expr.Inc("Balance", 10)`
+		if b.db.stmtRegistry().isExpr(vv.Interface()) {
+			buf.WriteString(fmt.Sprintf(" %s = ", b.db.dialect.Quote(kk)))
+			vals, err := b.db.stmtRegistry().BuildStatement(buf, reflect.ValueOf(vv.Interface()))
+			if err != nil {
+				return nil, fmt.Errorf("goloquent: %w", err)
+			}
+			buf.WriteByte(',')
+			args = append(args, vals...)
+			continue
+		}
 		buf.WriteString(fmt.Sprintf(" %s = %s,", b.db.dialect.Quote(kk), variable))
 		v, err := normalizeValue(vv.Interface())
 		if err != nil {
@@ -1005,36 +1327,55 @@ func (b *builder) updateWithMap(v reflect.Value) (*stmt, error) {
 	}, nil
 }
 
-func (b *builder) updateWithStruct(model interface{}) (*stmt, error) {
+// updateWithStruct : the version filter is return when the struct is versioned with a non-zero version
+func (b *builder) updateWithStruct(model interface{}) (*stmt, *Filter, error) {
 	vi := reflect.Indirect(reflect.ValueOf(model))
 	vv := reflect.New(vi.Type())
 	vv.Elem().Set(vi)
 	if err := checkSinglePtr(vv.Interface()); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	cols := newDictionary(b.query.projection)
+	cols := newDictionary(b.query.projectionFields())
 	buf, args := new(bytes.Buffer), make([]interface{}, 0)
+	codec, err := getStructCodec(vv.Interface())
+	if err != nil {
+		return nil, nil, err
+	}
+	touchTimestamps(vv, codec, false, true)
 	props, err := SaveStruct(vv.Interface())
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	vf := codec.versionField()
 	for _, p := range props {
 		name := p.Name()
-		if name == keyFieldName || (!cols.has(name) && p.isZero()) {
+		if name == keyFieldName || (vf != nil && name == vf.name) || (!cols.has(name) && p.isZero()) {
 			continue
 		}
 		it, err := p.Interface()
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		buf.WriteString(fmt.Sprintf("%s = %s,", b.db.dialect.Quote(p.Name()), variable))
 		args = append(args, it)
 	}
+	var filter *Filter
+	if vf != nil {
+		col := b.db.dialect.Quote(vf.name)
+		buf.WriteString(fmt.Sprintf("%s = %s + 1,", col, col))
+		if version := getVersion(getFieldByIndex(vi, vf.paths)); version != 0 {
+			filter = &Filter{
+				field:    vf.name,
+				operator: Equal,
+				value:    version,
+			}
+		}
+	}
 	buf.Truncate(buf.Len() - 1)
 	return &stmt{
 		statement: buf,
 		arguments: args,
-	}, nil
+	}, filter, nil
 }
 
 func (b *builder) updateMulti(ctx context.Context, v interface{}) error {
@@ -1048,28 +1389,42 @@ func (b *builder) updateMulti(ctx context.Context, v interface{}) error {
 		return fmt.Errorf("goloquent: missing table name")
 	}
 	buf.WriteString(fmt.Sprintf("UPDATE %s SET", b.db.dialect.GetTable(table)))
+	var versionFilter *Filter
 	switch vi.Type().Kind() {
 	case reflect.Map:
 		if vi.IsNil() || vi.Len() == 0 {
 			return nil
 		}
-		cmd, err := b.updateWithMap(vi)
+		cmd, err := b.updateWithMap(table, vi)
 		if err != nil {
 			return err
 		}
 		buf.WriteString(cmd.string())
 		args = append(args, cmd.arguments...)
 	case reflect.Struct:
-		cmd, err := b.updateWithStruct(v)
+		cmd, filter, err := b.updateWithStruct(v)
 		if err != nil {
 			return err
 		}
 		buf.WriteString(" " + cmd.string())
 		args = append(args, cmd.arguments...)
+		versionFilter = filter
 	default:
 		return fmt.Errorf("goloquent: unsupported data type %v on `Update`", vi.Type())
 	}
-	cmd, err := b.buildStmt(b.query)
+	var t reflect.Type
+	if vi.Kind() == reflect.Struct {
+		t = vi.Type()
+	}
+	query, err := b.applyGlobalScopes(ctx, b.query, table, t)
+	if err != nil {
+		return err
+	}
+	unversioned := query
+	if versionFilter != nil {
+		query.filters = append(append(make([]Filter, 0, len(query.filters)+1), query.filters...), *versionFilter)
+	}
+	cmd, err := b.buildStmt(query)
 	if err != nil {
 		return err
 	}
@@ -1085,10 +1440,48 @@ func (b *builder) updateMulti(ctx context.Context, v interface{}) error {
 		buf.WriteString(cmd.string())
 	}
 	buf.WriteString(";")
-	return b.db.client.execStmt(ctx, &stmt{
+	result, err := b.db.client.execResult(ctx, &stmt{
 		statement: buf,
 		arguments: append(args, cmd.arguments...),
 	})
+	if err != nil {
+		return err
+	}
+	if versionFilter != nil {
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n <= 0 {
+			return b.checkStale(ctx, table, unversioned, nil)
+		}
+	}
+	return nil
+}
+
+// checkStale : the entity is only stale when the records are matched without the version,
+// otherwise `missing` is return
+func (b *builder) checkStale(ctx context.Context, table string, query scope, missing error) error {
+	query.orders = nil
+	query.limit, query.offset = -1, -1
+	cmd, err := b.buildStmt(query)
+	if err != nil {
+		return err
+	}
+	buf := new(bytes.Buffer)
+	buf.WriteString(fmt.Sprintf("SELECT %s FROM %s",
+		b.db.dialect.Aggregate(aggregateCount, ""), b.db.dialect.GetTable(table)))
+	buf.WriteString(cmd.string())
+	buf.WriteString(";")
+	cmd.statement = buf
+	var n sql.NullInt64
+	if err := b.db.client.execQueryRow(ctx, cmd).Scan(&n); err != nil {
+		return b.db.client.wrapError(err)
+	}
+	if n.Int64 > 0 {
+		return ErrStaleEntity
+	}
+	return missing
 }
 
 func (b *builder) concatKeys(e *entity) (*stmt, error) {
@@ -1117,12 +1510,30 @@ func (b *builder) concatKeys(e *entity) (*stmt, error) {
 	}, nil
 }
 
-func (b *builder) softDeleteStmt(e *entity) (*stmt, error) {
+// softDeleteScope : exclude the soft deleted records, or only include them when `onlyTrashed`
+func softDeleteScope(query scope) scope {
+	f := Filter{field: softDeleteColumn, operator: Equal}
+	switch {
+	case query.onlyTrashed:
+		f.operator = NotEqual
+	case query.noScope:
+		return query
+	}
+	query.filters = append(append(make([]Filter, 0, len(query.filters)+1), query.filters...), f)
+	return query
+}
+
+// softDeleteStmt : the entity is restored when `isRestore`
+func (b *builder) softDeleteStmt(e *entity, isRestore bool) (*stmt, error) {
 	buf, args := new(bytes.Buffer), make([]interface{}, 0)
 	buf.WriteString(fmt.Sprintf("UPDATE %s SET ", b.db.dialect.GetTable(e.Name())))
 	buf.WriteString(fmt.Sprintf("%s = %s WHERE %s IN ",
 		b.db.dialect.Quote(softDeleteColumn), variable, b.db.dialect.Quote(pkColumn)))
-	args = append(args, time.Now().UTC().Format("2006-01-02 15:04:05"))
+	if isRestore {
+		args = append(args, nil)
+	} else {
+		args = append(args, time.Now().UTC().Format("2006-01-02 15:04:05"))
+	}
 	ss, err := b.concatKeys(e)
 	if err != nil {
 		return nil, err
@@ -1138,7 +1549,7 @@ func (b *builder) softDeleteStmt(e *entity) (*stmt, error) {
 func (b *builder) deleteStmt(e *entity, isSoftDelete bool) (*stmt, error) {
 	buf, args := new(bytes.Buffer), make([]interface{}, 0)
 	if isSoftDelete && e.hasSoftDelete() {
-		return b.softDeleteStmt(e)
+		return b.softDeleteStmt(e, false)
 	}
 	buf.WriteString(fmt.Sprintf("DELETE FROM %s WHERE %s IN ",
 		b.db.dialect.GetTable(e.Name()),
@@ -1161,15 +1572,66 @@ func (b *builder) delete(ctx context.Context, model interface{}, isSoftDelete bo
 		return err
 	}
 	e.setName(b.query.table)
+	if err := runHooks(ctx, b.db, e.slice, hookBeforeDelete); err != nil {
+		return err
+	}
 	cmd, err := b.deleteStmt(e, isSoftDelete)
 	if err != nil {
 		return err
 	}
+	if err := b.db.client.execStmt(ctx, cmd); err != nil {
+		return err
+	}
+	return runHooks(ctx, b.db, e.slice, hookAfterDelete)
+}
+
+func (b *builder) restore(ctx context.Context, model interface{}) error {
+	e, err := newEntity(model)
+	if err != nil {
+		return err
+	}
+	e.setName(b.query.table)
+	if !e.hasSoftDelete() {
+		return fmt.Errorf("goloquent: entity %q doesn't support soft delete", e.Name())
+	}
+	cmd, err := b.softDeleteStmt(e, true)
+	if err != nil {
+		return err
+	}
+	return b.db.client.execStmt(ctx, cmd)
+}
+
+func (b *builder) restoreByQuery(ctx context.Context) error {
+	query := b.query
+	if query.onlyTrashed {
+		query = softDeleteScope(query)
+	}
+	query, err := b.applyGlobalScopes(ctx, query, query.table, nil)
+	if err != nil {
+		return err
+	}
+	cmd, err := b.buildStmt(query)
+	if err != nil {
+		return err
+	}
+	buf := new(bytes.Buffer)
+	buf.WriteString(fmt.Sprintf("UPDATE %s SET %s = NULL",
+		b.db.dialect.GetTable(query.table), b.db.dialect.Quote(softDeleteColumn)))
+	buf.WriteString(cmd.string())
+	buf.WriteString(";")
+	cmd.statement = buf
 	return b.db.client.execStmt(ctx, cmd)
 }
 
 func (b *builder) deleteByQuery(ctx context.Context) error {
 	query := b.query
+	if query.onlyTrashed {
+		query = softDeleteScope(query)
+	}
+	query, err := b.applyGlobalScopes(ctx, query, query.table, nil)
+	if err != nil {
+		return err
+	}
 	cmd, err := b.buildStmt(query)
 	if err != nil {
 		return err
@@ -1184,11 +1646,7 @@ func (b *builder) deleteByQuery(ctx context.Context) error {
 
 func (b *builder) truncate(ctx context.Context, tables ...string) error {
 	for _, n := range tables {
-		buf := new(bytes.Buffer)
-		buf.WriteString(fmt.Sprintf("TRUNCATE TABLE %s;", b.db.dialect.GetTable(n)))
-		if err := b.db.client.execStmt(ctx, &stmt{
-			statement: buf,
-		}); err != nil {
+		if err := b.db.dialect.TruncateTable(ctx, n); err != nil {
 			return err
 		}
 	}
@@ -1216,20 +1674,39 @@ func (b *builder) scan(ctx context.Context, dest ...interface{}) error {
 	return nil
 }
 
-func (b *builder) runInTransaction(cb TransactionHandler) error {
+func (b *builder) runInTransaction(ctx context.Context, opts *sql.TxOptions, cb TransactionHandler) error {
+	if _, isOk := b.db.client.sqlCommon.(*sql.Tx); isOk {
+		return b.runInSavepoint(ctx, cb)
+	}
 	conn, isOk := b.db.client.sqlCommon.(*sql.DB)
 	if !isOk {
 		return fmt.Errorf("goloquent: unable to initiate transaction")
 	}
-	tx, err := conn.Begin()
+	policy := b.db.client.retry
+	for attempt := 1; ; attempt++ {
+		err := b.beginTransaction(ctx, conn, opts, cb, attempt)
+		if err == nil || !policy.shouldRetry(b.db.dialect, err, attempt) {
+			return err
+		}
+		if err := policy.wait(ctx, attempt); err != nil {
+			return err
+		}
+	}
+}
+
+// beginTransaction : execute the callback in a new transaction
+func (b *builder) beginTransaction(ctx context.Context, conn *sql.DB, opts *sql.TxOptions, cb TransactionHandler, attempt int) error {
+	tx, err := conn.BeginTx(ctx, opts)
 	if err != nil {
-		return fmt.Errorf("goloquent: unable to begin transaction, %v", err)
+		return fmt.Errorf("goloquent: unable to begin transaction, %w", err)
 	}
 
 	// transactional mode should use primary only
 	db := b.db.clone()
 	db.replica = nil
 	db.client.sqlCommon = tx
+	db.client.attempt = attempt
+	db.dialect = cloneDialect(db.dialect, db.client)
 	defer func() {
 		if r := recover(); r != nil {
 			defer tx.Rollback()
@@ -1242,6 +1719,30 @@ func (b *builder) runInTransaction(cb TransactionHandler) error {
 	return tx.Commit()
 }
 
+// runInSavepoint : nested transaction is rollback to the savepoint when the callback return error
+func (b *builder) runInSavepoint(ctx context.Context, cb TransactionHandler) error {
+	db := b.db.clone()
+	db.client.savepoint++
+	db.dialect = cloneDialect(db.dialect, db.client)
+	name := fmt.Sprintf("sp%d", db.client.savepoint)
+	if err := db.client.execSavepoint(ctx, "SAVEPOINT "+name); err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			db.client.execSavepoint(ctx, "ROLLBACK TO SAVEPOINT "+name)
+			panic(r)
+		}
+	}()
+	if err := cb(db); err != nil {
+		if rerr := db.client.execSavepoint(ctx, "ROLLBACK TO SAVEPOINT "+name); rerr != nil {
+			return rerr
+		}
+		return err
+	}
+	return db.client.execSavepoint(ctx, "RELEASE SAVEPOINT "+name)
+}
+
 func sha1Sign(s *Stmt) string {
 	h, rgx := sha1.New(), regexp.MustCompile(`(?i)FROM.+?(LIMIT)`)
 	bb := bytes.TrimSpace(bytes.TrimLeft(bytes.TrimRight(rgx.Find([]byte(s.String())), "LIMIT"), "FROM"))
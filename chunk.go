@@ -0,0 +1,71 @@
+package goloquent
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"cloud.google.com/go/datastore"
+)
+
+// Checkpoint : the progress of `Chunk`, persist the key to resume the interrupted chunk
+type Checkpoint struct {
+	// Key is the last processed primary key, the chunk start from the beginning when it's nil
+	Key *datastore.Key
+}
+
+// Chunk : walk the query result in primary key order, `size` records are loaded into the model on each
+// callback, the order and offset of the query are ignored
+func (q *Query) Chunk(ctx context.Context, size int, model interface{}, cb func() error) error {
+	return q.ChunkFrom(ctx, new(Checkpoint), size, model, cb)
+}
+
+// ChunkFrom : same as `Chunk` but it start after the key of the checkpoint, the checkpoint
+// is updated after every successful callback
+func (q *Query) ChunkFrom(ctx context.Context, cp *Checkpoint, size int, model interface{}, cb func() error) error {
+	if err := q.getError(); err != nil {
+		return err
+	}
+	if cp == nil {
+		return fmt.Errorf("goloquent: checkpoint cannot be nil")
+	}
+	if size <= 0 || size > maxLimit {
+		return fmt.Errorf("goloquent: invalid chunk size %d, it should between 1 and %d", size, maxLimit)
+	}
+	v := reflect.ValueOf(model)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("goloquent: chunk model must be pointer of slice")
+	}
+	e, err := newEntity(model)
+	if err != nil {
+		return err
+	}
+
+	for {
+		qq := q.clone()
+		qq.orders = nil
+		qq.offset = -1
+		if cp.Key != nil {
+			qq = qq.Where(pkColumn, ">", cp.Key)
+		}
+		if err := qq.OrderBy(pkColumn).Limit(size).Get(ctx, model); err != nil {
+			return err
+		}
+		vi := v.Elem()
+		n := vi.Len()
+		if n == 0 {
+			return nil
+		}
+		if err := cb(); err != nil {
+			return err
+		}
+		k, isOk := mustGetField(vi.Index(n-1), e.field(keyFieldName)).Interface().(*datastore.Key)
+		if !isOk || k == nil {
+			return fmt.Errorf("goloquent: entity %q has no primary key property", e.Name())
+		}
+		cp.Key = k
+		if n < size {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package goloquent
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+// copyFromDriver is the package of driver which recognise the statement of `COPY FROM`
+const copyFromDriver = "github.com/lib/pq"
+
+// SetCopyFrom : `Create` use `COPY FROM` for bulk import, it's only supported by postgres with `lib/pq` driver,
+// the error is return when it's enabled on the others
+func (db *DB) SetCopyFrom(enabled bool) error {
+	if enabled && !db.supportCopyFrom() {
+		return fmt.Errorf("goloquent: `COPY FROM` is not supported by the driver of %q", db.driver)
+	}
+	db.client.copyFrom = enabled
+	return nil
+}
+
+// supportCopyFrom : the dialect has `COPY FROM` statement and the connection is opened with `lib/pq`
+func (db *DB) supportCopyFrom() bool {
+	if db.dialect.CopyFromStmt("", nil) == "" {
+		return false
+	}
+	conn, isOk := db.client.sqlCommon.(*sql.DB)
+	if !isOk {
+		return false
+	}
+	t := reflect.TypeOf(conn.Driver())
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t != nil && t.PkgPath() == copyFromDriver
+}
+
+// copyFrom : import the rows through `COPY FROM` in transaction
+func (b *builder) copyFrom(ctx context.Context, e *entity, rows [][]interface{}) error {
+	query := b.db.dialect.CopyFromStmt(e.Name(), e.Columns())
+	return b.db.RunInTransactionContext(ctx, nil, func(txn *DB) error {
+		ss := &Stmt{
+			stmt:     stmt{statement: bytes.NewBufferString(query)},
+			replacer: txn.dialect,
+		}
+		ss.startTrace()
+		defer func() {
+			ss.stopTrace()
+			txn.client.consoleLog(ctx, ss)
+		}()
+		conn, err := txn.client.sqlCommon.PrepareContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("goloquent: unable to prepare copy statement : %w", err)
+		}
+		defer conn.Close()
+		for _, vals := range rows {
+			if _, err := conn.ExecContext(ctx, vals...); err != nil {
+				return txn.client.wrapError(err)
+			}
+		}
+		// flush the buffered rows
+		if _, err := conn.ExecContext(ctx); err != nil {
+			return txn.client.wrapError(err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,34 @@
+package goloquent
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestSetCopyFrom(t *testing.T) {
+	sql.Register("goloquent-fake", fakeDriver{})
+	conn, err := sql.Open("goloquent-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	db := &DB{driver: "postgres", dialect: new(postgres), client: Client{sqlCommon: conn}}
+	if err := db.SetCopyFrom(true); err == nil {
+		t.Fatal("Expected error when the driver is not lib/pq")
+	}
+	if db.client.copyFrom {
+		t.Fatal("Unexpected copy from is enabled")
+	}
+	if err := db.SetCopyFrom(false); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"cloud.google.com/go/datastore"
+	"github.com/RevenueMonster/goloquent/expr"
 )
 
 var isPkSimple = true
@@ -41,6 +42,12 @@ const (
 var (
 	ErrNoSuchEntity  = fmt.Errorf("goloquent: entity not found")
 	ErrInvalidCursor = fmt.Errorf("goloquent: invalid cursor")
+	ErrDuplicateKey  = fmt.Errorf("goloquent: duplicate key")
+	ErrDeadlock      = fmt.Errorf("goloquent: deadlock")
+	ErrLockTimeout   = fmt.Errorf("goloquent: lock timeout")
+	ErrConnection    = fmt.Errorf("goloquent: connection failure")
+	ErrInvalidField  = fmt.Errorf("goloquent: invalid field")
+	ErrStaleEntity   = fmt.Errorf("goloquent: entity is modified by others")
 )
 
 // Config :
@@ -87,10 +94,18 @@ type Client struct {
 	CharSet
 	dialect Dialect
 	logger  LogHandler
+	// savepoint is the depth of nested transaction
+	savepoint int
+	retry     RetryPolicy
+	// attempt is the execution count of the transaction
+	attempt int
+	// copyFrom enable `COPY FROM` on `Create` when it's supported by dialect
+	copyFrom bool
 }
 
 func (c Client) consoleLog(ctx context.Context, s *Stmt) {
 	if c.logger != nil {
+		s.attempt = c.attempt
 		c.logger(ctx, s)
 	}
 }
@@ -109,6 +124,11 @@ func (c *Client) compileStmt(query string, args ...interface{}) *Stmt {
 }
 
 func (c Client) execStmt(ctx context.Context, s *stmt) error {
+	_, err := c.execResult(ctx, s)
+	return err
+}
+
+func (c Client) execResult(ctx context.Context, s *stmt) (sql.Result, error) {
 	ss := &Stmt{
 		stmt:     *s,
 		replacer: c.dialect,
@@ -119,6 +139,25 @@ func (c Client) execStmt(ctx context.Context, s *stmt) error {
 		c.consoleLog(ctx, ss)
 	}()
 	result, err := c.PrepareExec(ctx, ss.Raw(), ss.arguments...)
+	if err != nil {
+		return nil, err
+	}
+	ss.Result = result
+	return result, nil
+}
+
+// execSavepoint : savepoint statement is not executed as prepared statement
+func (c Client) execSavepoint(ctx context.Context, query string) error {
+	ss := &Stmt{
+		stmt:     stmt{statement: bytes.NewBufferString(query)},
+		replacer: c.dialect,
+	}
+	ss.startTrace()
+	defer func() {
+		ss.stopTrace()
+		c.consoleLog(ctx, ss)
+	}()
+	result, err := c.Exec(ctx, query)
 	if err != nil {
 		return err
 	}
@@ -160,12 +199,15 @@ func (c *Client) execQueryRow(ctx context.Context, s *stmt) *sql.Row {
 func (c Client) PrepareExec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
 	conn, err := c.sqlCommon.PrepareContext(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("goloquent: unable to prepare sql statement : %v", err)
+		if kind := c.dialect.ClassifyError(err); kind != nil {
+			return nil, &Error{Kind: kind, Err: err}
+		}
+		return nil, fmt.Errorf("goloquent: unable to prepare sql statement : %w", err)
 	}
 	defer conn.Close()
 	result, err := conn.Exec(args...)
 	if err != nil {
-		return nil, fmt.Errorf("goloquent: %v", err)
+		return nil, c.wrapError(err)
 	}
 	return result, nil
 }
@@ -174,7 +216,7 @@ func (c Client) PrepareExec(ctx context.Context, query string, args ...interface
 func (c Client) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
 	result, err := c.sqlCommon.ExecContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("goloquent: %v", err)
+		return nil, c.wrapError(err)
 	}
 	return result, nil
 }
@@ -183,7 +225,7 @@ func (c Client) Exec(ctx context.Context, query string, args ...interface{}) (sq
 func (c Client) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
 	rows, err := c.sqlCommon.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("goloquent: %v", err)
+		return nil, c.wrapError(err)
 	}
 	return rows, nil
 }
@@ -230,6 +272,11 @@ func NewDB(ctx context.Context, driver string, charset CharSet, conn sqlCommon,
 	}
 }
 
+// stmtRegistry : the statement registry of the dialect
+func (db *DB) stmtRegistry() *StmtRegistry {
+	return stmtRegistryOf(db.driver, db.dialect)
+}
+
 // clone a new connection
 func (db *DB) clone() *DB {
 	return &DB{
@@ -274,7 +321,22 @@ func (db *DB) Table(name string) *Table {
 
 // Migrate :
 func (db *DB) Migrate(ctx context.Context, model ...interface{}) error {
-	return newBuilder(db.NewQuery(), operationDDL).migrateMultiple(ctx, model)
+	return db.MigrateWithOptions(ctx, MigrateOptions{}, model...)
+}
+
+// MigrateWithOptions : migrate the models with `MigrateOptions` to enable unsafe migration
+func (db *DB) MigrateWithOptions(ctx context.Context, opts MigrateOptions, model ...interface{}) error {
+	return newBuilder(db.NewQuery(), operationDDL).migrateMultiple(ctx, model, opts)
+}
+
+// PlanMigration : return the schema changes and the statements of migration without executing it
+func (db *DB) PlanMigration(ctx context.Context, model ...interface{}) (*MigrationPlan, error) {
+	return db.PlanMigrationWithOptions(ctx, MigrateOptions{}, model...)
+}
+
+// PlanMigrationWithOptions :
+func (db *DB) PlanMigrationWithOptions(ctx context.Context, opts MigrateOptions, model ...interface{}) (*MigrationPlan, error) {
+	return newBuilder(db.NewQuery(), operationDDL).planMigration(ctx, model, opts)
 }
 
 // Omit :
@@ -321,6 +383,11 @@ func (db *DB) Destroy(ctx context.Context, model interface{}) error {
 	return newBuilder(db.NewQuery(), operationWrite).delete(ctx, model, false)
 }
 
+// Restore : restore the soft deleted entity
+func (db *DB) Restore(ctx context.Context, model interface{}) error {
+	return newBuilder(db.NewQuery(), operationWrite).restore(ctx, model)
+}
+
 // Truncate :
 func (db *DB) Truncate(ctx context.Context, model ...interface{}) error {
 	ns := make([]string, 0, len(model))
@@ -350,6 +417,26 @@ func (db *DB) Select(fields ...string) *Query {
 	return db.NewQuery().Select(fields...)
 }
 
+// SelectAggregate :
+func (db *DB) SelectAggregate(aggrs ...expr.Aggregate) *Query {
+	return db.NewQuery().SelectAggregate(aggrs...)
+}
+
+// With :
+func (db *DB) With(fields ...string) *Query {
+	return db.NewQuery().With(fields...)
+}
+
+// WithParent :
+func (db *DB) WithParent(dst interface{}) *Query {
+	return db.NewQuery().WithParent(dst)
+}
+
+// WithChildren :
+func (db *DB) WithChildren(kind string, dst interface{}) *Query {
+	return db.NewQuery().WithChildren(kind, dst)
+}
+
 // Find :
 func (db *DB) Find(ctx context.Context, key *datastore.Key, model interface{}) error {
 	return db.NewQuery().Find(ctx, key, model)
@@ -370,6 +457,26 @@ func (db *DB) Paginate(ctx context.Context, p *Pagination, model interface{}) er
 	return db.NewQuery().Paginate(ctx, p, model)
 }
 
+// Rows :
+func (db *DB) Rows(ctx context.Context, model interface{}) (*Rows, error) {
+	return db.NewQuery().Rows(ctx, model)
+}
+
+// Each :
+func (db *DB) Each(ctx context.Context, model interface{}, cb func() error) error {
+	return db.NewQuery().Each(ctx, model, cb)
+}
+
+// Chunk :
+func (db *DB) Chunk(ctx context.Context, size int, model interface{}, cb func() error) error {
+	return db.NewQuery().Chunk(ctx, size, model, cb)
+}
+
+// ChunkFrom :
+func (db *DB) ChunkFrom(ctx context.Context, cp *Checkpoint, size int, model interface{}, cb func() error) error {
+	return db.NewQuery().ChunkFrom(ctx, cp, size, model, cb)
+}
+
 // Ancestor :
 func (db *DB) Ancestor(ancestor *datastore.Key) *Query {
 	return db.NewQuery().Ancestor(ancestor)
@@ -392,7 +499,13 @@ func (db *DB) MatchAgainst(fields []string, value ...string) *Query {
 
 // RunInTransaction :
 func (db *DB) RunInTransaction(cb TransactionHandler) error {
-	return newBuilder(db.NewQuery(), operationDDL).runInTransaction(cb)
+	return newBuilder(db.NewQuery(), operationDDL).runInTransaction(context.Background(), nil, cb)
+}
+
+// RunInTransactionContext : the transaction is started with the context and options,
+// nested call inside the callback will become a savepoint of the transaction (the options is ignored)
+func (db *DB) RunInTransactionContext(ctx context.Context, opts *sql.TxOptions, cb TransactionHandler) error {
+	return newBuilder(db.NewQuery(), operationDDL).runInTransaction(ctx, opts, cb)
 }
 
 // Close :
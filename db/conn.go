@@ -30,6 +30,10 @@ type Config struct {
 	CharSet    *goloquent.CharSet
 	Logger     goloquent.LogHandler
 	Native     goloquent.NativeHandler
+	// RetryPolicy of the transaction, eg: retry on deadlock
+	RetryPolicy *goloquent.RetryPolicy
+	// CopyFrom enable bulk import of `Create` through `COPY FROM` (postgres with `lib/pq` only)
+	CopyFrom bool
 }
 
 // Open :
@@ -71,6 +75,13 @@ func Open(ctx context.Context, driver string, conf Config) (*goloquent.DB, error
 	}
 
 	db := goloquent.NewDB(ctx, driver, *config.CharSet, conn, dialect, conf.Logger)
+	if conf.RetryPolicy != nil {
+		db.SetRetryPolicy(*conf.RetryPolicy)
+	}
+	if err := db.SetCopyFrom(conf.CopyFrom); err != nil {
+		conn.Close()
+		return nil, err
+	}
 	pool[conf.Database] = db
 	connPool.Store(driver, pool)
 	// Override defaultDB whenever we initialise a new connection
@@ -8,6 +8,7 @@ import (
 
 	"cloud.google.com/go/datastore"
 	"github.com/RevenueMonster/goloquent"
+	"github.com/RevenueMonster/goloquent/expr"
 )
 
 // Connection :
@@ -51,6 +52,36 @@ func Migrate(ctx context.Context, model ...interface{}) error {
 	return defaultDB.Migrate(ctx, model...)
 }
 
+// MigrateWithOptions :
+func MigrateWithOptions(ctx context.Context, opts goloquent.MigrateOptions, model ...interface{}) error {
+	return defaultDB.MigrateWithOptions(ctx, opts, model...)
+}
+
+// PlanMigration :
+func PlanMigration(ctx context.Context, model ...interface{}) (*goloquent.MigrationPlan, error) {
+	return defaultDB.PlanMigration(ctx, model...)
+}
+
+// PlanMigrationWithOptions :
+func PlanMigrationWithOptions(ctx context.Context, opts goloquent.MigrateOptions, model ...interface{}) (*goloquent.MigrationPlan, error) {
+	return defaultDB.PlanMigrationWithOptions(ctx, opts, model...)
+}
+
+// MigrateUp :
+func MigrateUp(ctx context.Context) error {
+	return defaultDB.MigrateUp(ctx)
+}
+
+// MigrateDown :
+func MigrateDown(ctx context.Context, n int) error {
+	return defaultDB.MigrateDown(ctx, n)
+}
+
+// MigrationStatus :
+func MigrationStatus(ctx context.Context) ([]goloquent.MigrationState, error) {
+	return defaultDB.MigrationStatus(ctx)
+}
+
 // Omit :
 func Omit(fields ...string) goloquent.Replacer {
 	return defaultDB.Omit(fields...)
@@ -82,6 +113,11 @@ func Destroy(ctx context.Context, model interface{}) error {
 	return defaultDB.Destroy(ctx, model)
 }
 
+// Restore :
+func Restore(ctx context.Context, model interface{}) error {
+	return defaultDB.Restore(ctx, model)
+}
+
 // Save :
 func Save(ctx context.Context, model interface{}) error {
 	return defaultDB.Save(ctx, model)
@@ -107,6 +143,26 @@ func Paginate(ctx context.Context, p *goloquent.Pagination, model interface{}) e
 	return defaultDB.Paginate(ctx, p, model)
 }
 
+// Rows :
+func Rows(ctx context.Context, model interface{}) (*goloquent.Rows, error) {
+	return defaultDB.Rows(ctx, model)
+}
+
+// Each :
+func Each(ctx context.Context, model interface{}, cb func() error) error {
+	return defaultDB.Each(ctx, model, cb)
+}
+
+// Chunk :
+func Chunk(ctx context.Context, size int, model interface{}, cb func() error) error {
+	return defaultDB.Chunk(ctx, size, model, cb)
+}
+
+// ChunkFrom :
+func ChunkFrom(ctx context.Context, cp *goloquent.Checkpoint, size int, model interface{}, cb func() error) error {
+	return defaultDB.ChunkFrom(ctx, cp, size, model, cb)
+}
+
 // NewQuery :
 func NewQuery() *goloquent.Query {
 	return defaultDB.NewQuery()
@@ -117,6 +173,26 @@ func Select(fields ...string) *goloquent.Query {
 	return defaultDB.Select(fields...)
 }
 
+// SelectAggregate :
+func SelectAggregate(aggrs ...expr.Aggregate) *goloquent.Query {
+	return defaultDB.SelectAggregate(aggrs...)
+}
+
+// With :
+func With(fields ...string) *goloquent.Query {
+	return defaultDB.With(fields...)
+}
+
+// WithParent :
+func WithParent(dst interface{}) *goloquent.Query {
+	return defaultDB.WithParent(dst)
+}
+
+// WithChildren :
+func WithChildren(kind string, dst interface{}) *goloquent.Query {
+	return defaultDB.WithChildren(kind, dst)
+}
+
 // Ancestor :
 func Ancestor(ancestor *datastore.Key) *goloquent.Query {
 	return defaultDB.NewQuery().Ancestor(ancestor)
@@ -132,6 +208,16 @@ func Unscoped() *goloquent.Query {
 	return defaultDB.NewQuery().Unscoped()
 }
 
+// WithoutScope :
+func WithoutScope(names ...string) *goloquent.Query {
+	return defaultDB.NewQuery().WithoutScope(names...)
+}
+
+// OnlyTrashed :
+func OnlyTrashed() *goloquent.Query {
+	return defaultDB.NewQuery().OnlyTrashed()
+}
+
 // DistinctOn :
 func DistinctOn(fields ...string) *goloquent.Query {
 	return defaultDB.NewQuery().DistinctOn(fields...)
@@ -192,6 +278,11 @@ func RunInTransaction(cb goloquent.TransactionHandler) error {
 	return defaultDB.RunInTransaction(cb)
 }
 
+// RunInTransactionContext :
+func RunInTransactionContext(ctx context.Context, opts *sql.TxOptions, cb goloquent.TransactionHandler) error {
+	return defaultDB.RunInTransactionContext(ctx, opts, cb)
+}
+
 // Truncate :
 func Truncate(ctx context.Context, model ...interface{}) error {
 	return defaultDB.Truncate(ctx, model...)
@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"encoding/json"
 	"reflect"
+
+	"cloud.google.com/go/datastore"
 )
 
 // Dialect :
@@ -17,19 +19,36 @@ type Dialect interface {
 	Quote(n string) string
 	Bind(i uint) string
 	FilterJSON(f Filter) (s string, args []interface{}, err error)
+	FilterGeo(f Filter) (s string, args []interface{}, err error)
+	GeoDistance(field string, p datastore.GeoPoint) (s string, args []interface{})
+	Aggregate(fn, field string) string
+	FullKey(column, kind string) string
+	FieldPosition(column string, numOfValues int) string
 	JSONMarshal(i interface{}) (b json.RawMessage)
 	Value(v interface{}) string
 	GetSchema(c Column) []Schema
+	GetJSONIndexes(tb string, c Column) []IndexPlan
 	DataType(s Schema) string
 	HasTable(ctx context.Context, tb string) bool
 	HasIndex(ctx context.Context, tb, idx string) bool
 	GetColumns(ctx context.Context, tb string) (cols []string)
 	GetIndexes(ctx context.Context, tb string) (idxs []string)
+	GetColumnTypes(ctx context.Context, tb string) (types map[string]string)
 	CreateTable(ctx context.Context, tb string, cols []Column) error
-	AlterTable(ctx context.Context, tb string, cols []Column, unsafe bool) error
+	AlterTable(ctx context.Context, tb string, cols []Column, opts MigrateOptions) error
+	CreateTableStmt(plan *TablePlan) []string
+	AlterTableStmt(plan *TablePlan, opts MigrateOptions) []string
 	OnConflictUpdate(tb string, cols []string) string
 	UpdateWithLimit() bool
+	NullSafeEqual() string
+	MaxBindParameters() int
+	CopyFromStmt(tb string, cols []string) string
 	ReplaceInto(ctx context.Context, src, dst string) error
+	TruncateTable(ctx context.Context, tb string) error
+	LockMode(mode locked) string
+	TransactionalDDL() bool
+	IsRetryable(err error) bool
+	ClassifyError(err error) error
 }
 
 var (
@@ -50,3 +69,17 @@ func GetDialect(driver string) (d Dialect, isValid bool) {
 	}
 	return
 }
+
+// cloneDialect will copy the dialect and bind it to the client,
+// so the dialect is using the same connection (eg: transaction) with the caller
+func cloneDialect(d Dialect, c Client) Dialect {
+	v := reflect.ValueOf(d)
+	if v.Kind() != reflect.Ptr {
+		return d
+	}
+	vi := reflect.New(v.Type().Elem())
+	vi.Elem().Set(v.Elem())
+	clone := vi.Interface().(Dialect)
+	clone.SetDB(c)
+	return clone
+}
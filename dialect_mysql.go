@@ -9,9 +9,8 @@ import (
 	"reflect"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
-
-	"github.com/RevenueMonster/goloquent/types"
 )
 
 type mysql struct {
@@ -82,6 +81,10 @@ func (s mysql) Bind(uint) string {
 func (s mysql) DataType(sc Schema) string {
 	buf := new(bytes.Buffer)
 	buf.WriteString(sc.DataType)
+	if sc.Generated != "" {
+		buf.WriteString(fmt.Sprintf(" AS (%s) STORED", sc.Generated))
+		return buf.String()
+	}
 	if sc.IsUnsigned {
 		buf.WriteString(" UNSIGNED")
 	}
@@ -110,67 +113,81 @@ func (s mysql) OnConflictUpdate(table string, cols []string) string {
 	return buf.String()
 }
 
-func (s mysql) CreateTable(ctx context.Context, table string, columns []Column) error {
+func (s *mysql) CreateTable(ctx context.Context, table string, columns []Column) error {
+	return execStmts(ctx, s.db, s.CreateTableStmt(newTablePlan(s, table, columns)))
+}
+
+func (s *mysql) AlterTable(ctx context.Context, table string, columns []Column, opts MigrateOptions) error {
+	return execStmts(ctx, s.db, s.AlterTableStmt(diffTable(ctx, s, table, columns), opts))
+}
+
+// CreateTableStmt :
+func (s mysql) CreateTableStmt(plan *TablePlan) []string {
 	buf := new(bytes.Buffer)
-	buf.WriteString(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (", s.GetTable(table)))
-	for _, c := range columns {
-		for _, ss := range s.GetSchema(c) {
-			buf.WriteString(fmt.Sprintf("%s %s,", s.Quote(ss.Name), s.DataType(ss)))
-			if ss.IsIndexed || c.field.typeOf == typeOfSoftDelete {
-				idx := fmt.Sprintf("%s_%s_%s", table, ss.Name, "idx")
-				buf.WriteString(fmt.Sprintf("INDEX %s (%s),", s.Quote(idx), s.Quote(ss.Name)))
-			}
-		}
+	buf.WriteString(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (", s.GetTable(plan.Table)))
+	for _, c := range plan.AddedColumns {
+		buf.WriteString(fmt.Sprintf("%s %s,", s.Quote(c.Name), s.DataType(c.Schema)))
+	}
+	for _, idx := range plan.AddedIndexes {
+		buf.WriteString(fmt.Sprintf("INDEX %s (%s),", s.Quote(idx.Name), quoteColumns(s.Quote, idx.Columns)))
 	}
 	buf.WriteString(fmt.Sprintf("PRIMARY KEY (%s)", s.Quote(pkColumn)))
 	buf.WriteString(fmt.Sprintf(") ENGINE=InnoDB DEFAULT CHARSET=%s COLLATE=%s;",
 		s.Quote(s.db.CharSet.Encoding), s.Quote(s.db.CharSet.Collation)))
-	return s.db.execStmt(ctx, &stmt{statement: buf})
+	return []string{buf.String()}
 }
 
-func (s *mysql) AlterTable(ctx context.Context, table string, columns []Column, unsafe bool) error {
-	cols := types.StringSlice(s.GetColumns(ctx, table))
-	idxs := types.StringSlice(s.GetIndexes(ctx, table))
-
-	var idx string
-	blr := new(bytes.Buffer)
-	blr.WriteString(`ALTER TABLE ` + s.GetTable(table) + ` `)
-	suffix := "FIRST"
-	for _, c := range columns {
-		for _, ss := range s.GetSchema(c) {
-			if cols.IndexOf(ss.Name) > -1 {
-				blr.WriteString(`MODIFY`)
-			} else {
-				blr.WriteString(`ADD`)
-			}
-
-			blr.WriteString(` ` + s.Quote(ss.Name) + ` `)
-			blr.WriteString(s.DataType(ss) + ` ` + suffix)
-			suffix = `AFTER ` + s.Quote(ss.Name)
-
-			if ss.IsIndexed || c.field.typeOf == typeOfSoftDelete {
-				idx = table + `_` + ss.Name + `_idx`
-				if idxs.IndexOf(idx) < 0 {
-					blr.WriteRune(',')
-					blr.WriteString(`ADD INDEX ` + s.Quote(idx))
-					blr.WriteString(` (` + s.Quote(ss.Name) + `)`)
-				}
-			}
-			blr.WriteRune(',')
+// AlterTableStmt : changed column, orphan column and index are only applied when it's enabled in options
+func (s mysql) AlterTableStmt(plan *TablePlan, opts MigrateOptions) []string {
+	actions := make([]string, 0)
+	for _, c := range plan.AddedColumns {
+		suffix := "FIRST"
+		if c.After != "" {
+			suffix = "AFTER " + s.Quote(c.After)
 		}
+		actions = append(actions, fmt.Sprintf("ADD %s %s %s", s.Quote(c.Name), s.DataType(c.Schema), suffix))
+	}
+	// the existing columns are always modified, so the nullability, default value and charset are applied,
+	// generated column is skipped as it's only changed by the expression
+	for _, c := range plan.ExistingColumns {
+		if c.Generated != "" {
+			continue
+		}
+		suffix := "FIRST"
+		if c.After != "" {
+			suffix = "AFTER " + s.Quote(c.After)
+		}
+		actions = append(actions, fmt.Sprintf("MODIFY %s %s %s", s.Quote(c.Name), s.DataType(c.Schema), suffix))
+	}
+	if opts.AlterTypes {
+		for _, c := range plan.ChangedColumns {
+			actions = append(actions, fmt.Sprintf("MODIFY %s %s", s.Quote(c.Name), s.DataType(c.Schema)))
+		}
+	}
+	for _, idx := range plan.AddedIndexes {
+		actions = append(actions, fmt.Sprintf("ADD INDEX %s (%s)", s.Quote(idx.Name), quoteColumns(s.Quote, idx.Columns)))
+	}
+	if opts.DropIndexes {
+		for _, idx := range plan.DroppedIndexes {
+			actions = append(actions, fmt.Sprintf("DROP INDEX %s", s.Quote(idx)))
+		}
+	}
+	if opts.DropColumns {
+		for _, col := range plan.DroppedColumns {
+			actions = append(actions, fmt.Sprintf("DROP COLUMN %s", s.Quote(col)))
+		}
+	}
+	if len(actions) <= 0 {
+		return nil
 	}
 
-	// for _, col := range cols.keys() {
-	// 	blr.WriteString(fmt.Sprintf("DROP COLUMN %s,", s.Quote(col)))
-	// }
-	// for _, idx := range idxs.keys() {
-	// 	buf.WriteString(fmt.Sprintf("DROP INDEX %s,", s.Quote(idx)))
-	// }
-
-	blr.WriteString(` CHARACTER SET ` + s.Quote(s.db.CharSet.Encoding))
-	blr.WriteString(` COLLATE ` + s.Quote(s.db.CharSet.Collation))
-	blr.WriteRune(';')
-	return s.db.execStmt(ctx, &stmt{statement: blr})
+	buf := new(bytes.Buffer)
+	buf.WriteString(`ALTER TABLE ` + s.GetTable(plan.Table) + ` `)
+	buf.WriteString(strings.Join(actions, ", "))
+	buf.WriteString(`, CHARACTER SET ` + s.Quote(s.db.CharSet.Encoding))
+	buf.WriteString(` COLLATE ` + s.Quote(s.db.CharSet.Collation))
+	buf.WriteRune(';')
+	return []string{buf.String()}
 }
 
 func (s mysql) ToString(it interface{}) string {
@@ -0,0 +1,40 @@
+package goloquent
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMySQLAlterTableStmt(t *testing.T) {
+	plan := &TablePlan{
+		Table: "User",
+		ExistingColumns: []ColumnPlan{
+			{Schema: Schema{Name: "Name", DataType: "varchar(191)", DefaultValue: ""}},
+			{Schema: Schema{Name: "Age", DataType: "int", IsNullable: true}, After: "Name"},
+			{Schema: Schema{Name: "Address$City", DataType: "varchar(191)", IsNullable: true, Generated: "`Address`->>'$.City'"}, After: "Age"},
+		},
+		ChangedColumns: []ColumnPlan{
+			{Schema: Schema{Name: "Price", DataType: "decimal(10,2)", DefaultValue: OmitDefault(nil)}, PrevDataType: "int", After: "Age"},
+		},
+	}
+
+	stmts := new(mysql).AlterTableStmt(plan, MigrateOptions{})
+	if len(stmts) != 1 {
+		t.Fatalf("Unexpected number of statements, %d", len(stmts))
+	}
+	if !strings.Contains(stmts[0], "MODIFY `Name` varchar(191) NOT NULL DEFAULT \"\" FIRST") ||
+		!strings.Contains(stmts[0], "MODIFY `Age` int AFTER `Name`") {
+		t.Fatalf("Existing columns are not modified, %s", stmts[0])
+	}
+	if strings.Contains(stmts[0], "`Address$City`") {
+		t.Fatalf("Generated column shouldn't be modified, %s", stmts[0])
+	}
+	if strings.Contains(stmts[0], "`Price`") {
+		t.Fatalf("Changed column shouldn't be modified without AlterTypes, %s", stmts[0])
+	}
+
+	stmts = new(mysql).AlterTableStmt(plan, MigrateOptions{AlterTypes: true})
+	if len(stmts) != 1 || !strings.Contains(stmts[0], "MODIFY `Price` decimal(10,2) NOT NULL") {
+		t.Fatalf("Changed column is not modified, %v", stmts)
+	}
+}
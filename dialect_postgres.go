@@ -10,6 +10,8 @@ import (
 	"reflect"
 	"strings"
 	"time"
+
+	"cloud.google.com/go/datastore"
 )
 
 type postgres struct {
@@ -79,16 +81,39 @@ func (p postgres) Bind(i uint) string {
 }
 
 func (p postgres) SplitJSON(name string) string {
+	return p.splitJSON("", name)
+}
+
+// splitJSON : the column is qualified by the table when it's not empty
+func (p postgres) splitJSON(table, name string) string {
 	paths := strings.SplitN(name, ">", 2)
 	if len(paths) <= 1 {
-		return p.Quote(paths[0])
+		return qualifyColumn(p.Quote, table, paths[0])
 	}
 	vv := strings.Split(strings.TrimSpace(paths[1]), `.`)
 	return fmt.Sprintf(`%s->%s`,
-		p.Quote(strings.TrimSpace(paths[0])),
+		qualifyColumn(p.Quote, table, strings.TrimSpace(paths[0])),
 		`'`+strings.Join(vv, p.Value(`->`))+`'`)
 }
 
+// Aggregate : the value of json path is extracted as text and cast to numeric except `COUNT`
+func (p postgres) Aggregate(fn, field string) string {
+	if field == "" {
+		return fn + "(*)"
+	}
+	paths := strings.SplitN(field, ">", 2)
+	if len(paths) <= 1 {
+		return fmt.Sprintf("%s(%s)", fn, p.Quote(field))
+	}
+	name := fmt.Sprintf("%s#>>'{%s}'",
+		p.Quote(strings.TrimSpace(paths[0])),
+		strings.Join(strings.Split(strings.TrimSpace(paths[1]), "."), ","))
+	if fn == "COUNT" {
+		return fmt.Sprintf("%s(%s)", fn, name)
+	}
+	return fmt.Sprintf("%s((%s)::numeric)", fn, name)
+}
+
 func (p postgres) JSONMarshal(v interface{}) (b json.RawMessage) {
 	switch vi := v.(type) {
 	case json.RawMessage:
@@ -103,6 +128,18 @@ func (p postgres) JSONMarshal(v interface{}) (b json.RawMessage) {
 	return
 }
 
+// FullKey : the parent path is the key trimmed until the last `/`
+func (p postgres) FullKey(column, kind string) string {
+	parent := fmt.Sprintf("rtrim(%s,replace(%s,'/',''))", column, column)
+	return fmt.Sprintf("%s||'%s,'||substr(%s,length(%s)+1)",
+		parent, strings.Replace(kind, "'", "''", -1), column, parent)
+}
+
+// FieldPosition : `FIELD` is emulated with `CASE WHEN`
+func (p postgres) FieldPosition(column string, numOfValues int) string {
+	return caseFieldPosition(column, numOfValues)
+}
+
 func (p postgres) FilterJSON(f Filter) (string, []interface{}, error) {
 	vv, err := f.Interface()
 	if err != nil {
@@ -111,7 +148,7 @@ func (p postgres) FilterJSON(f Filter) (string, []interface{}, error) {
 	if vv == nil {
 		vv = json.RawMessage("null")
 	}
-	name := p.SplitJSON(f.Field())
+	name := p.splitJSON(f.Table(), f.Field())
 	buf, args := new(bytes.Buffer), make([]interface{}, 0)
 	switch f.operator {
 	case Equal:
@@ -203,6 +240,31 @@ func (p postgres) Value(it interface{}) string {
 	return str
 }
 
+func (p postgres) geoLatLng(table, field string) (string, string) {
+	col := qualifyColumn(p.Quote, table, field)
+	return fmt.Sprintf("(%s->>'latitude')::float8", col), fmt.Sprintf("(%s->>'longitude')::float8", col)
+}
+
+// FilterGeo : filter the `datastore.GeoPoint` using haversine formula
+func (p postgres) FilterGeo(f Filter) (string, []interface{}, error) {
+	lat, lng := p.geoLatLng(f.Table(), f.Field())
+	switch vi := f.value.(type) {
+	case geoRadius:
+		str, args := haversine(lat, lng, vi.point)
+		return fmt.Sprintf("%s <= %s", str, variable), append(args, vi.distance), nil
+	case geoBox:
+		str, args := filterGeoBox(lat, lng, vi)
+		return str, args, nil
+	}
+	return "", nil, fmt.Errorf("unsupported operator")
+}
+
+// GeoDistance :
+func (p postgres) GeoDistance(field string, pt datastore.GeoPoint) (string, []interface{}) {
+	lat, lng := p.geoLatLng("", field)
+	return haversine(lat, lng, pt)
+}
+
 // DataType :
 func (p postgres) DataType(sc Schema) string {
 	buf := new(bytes.Buffer)
@@ -220,16 +282,30 @@ func (p postgres) DataType(sc Schema) string {
 	return buf.String()
 }
 
+// NullSafeEqual :
+func (p postgres) NullSafeEqual() string {
+	return "IS NOT DISTINCT FROM"
+}
+
+// OnConflictUpdate : the conflicting row is updated with the proposed value of `EXCLUDED`
 func (p postgres) OnConflictUpdate(table string, cols []string) string {
 	buf := new(bytes.Buffer)
 	buf.WriteString(fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET ", p.Quote(pkColumn)))
 	for _, c := range cols {
-		buf.WriteString(fmt.Sprintf("%s = %s.%s,", p.Quote(c), p.GetTable(table), p.Quote(c)))
+		buf.WriteString(fmt.Sprintf("%s = EXCLUDED.%s,", p.Quote(c), p.Quote(c)))
 	}
 	buf.Truncate(buf.Len() - 1)
 	return buf.String()
 }
 
+// GetJSONIndexes : json path is indexed by expression index
+func (p postgres) GetJSONIndexes(table string, c Column) []IndexPlan {
+	if p.GetSchema(c)[0].DataType != "jsonb" {
+		return nil
+	}
+	return jsonIndexPlans(table, c, p.SplitJSON)
+}
+
 func (p postgres) GetSchema(c Column) []Schema {
 	f := c.field
 	root := f.getRoot()
@@ -250,7 +326,7 @@ func (p postgres) GetSchema(c Column) []Schema {
 		if t == typeOfPtrKey {
 			if f.name == keyFieldName {
 				return []Schema{
-					{pkColumn, fmt.Sprintf("varchar(%d)", pkLen), OmitDefault(nil), false, false, false, latin1CharSet},
+					{Name: pkColumn, DataType: fmt.Sprintf("varchar(%d)", pkLen), DefaultValue: OmitDefault(nil), CharSet: latin1CharSet},
 				}
 			}
 			sc.IsIndexed = true
@@ -400,121 +476,179 @@ func (p *postgres) ToString(it interface{}) string {
 	return v
 }
 
-func (p *postgres) CreateTable(ctx context.Context, table string, columns []Column) error {
-	idxs := make([]string, 0, len(columns))
-	conn := p.db.sqlCommon.(*sql.DB)
-	tx, err := conn.Begin()
+// execInTx will execute the statements in one transaction unless it's already in a transaction
+func (p *postgres) execInTx(ctx context.Context, stmts []string) error {
+	if len(stmts) <= 0 {
+		return nil
+	}
+	conn, isOk := p.db.sqlCommon.(*sql.DB)
+	if !isOk {
+		return execStmts(ctx, p.db, stmts)
+	}
+	txn, err := conn.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	defer tx.Rollback()
+	defer txn.Rollback()
+	client := p.db
+	client.sqlCommon = txn
+	if err := execStmts(ctx, client, stmts); err != nil {
+		return err
+	}
+	return txn.Commit()
+}
+
+func (p *postgres) CreateTable(ctx context.Context, table string, columns []Column) error {
+	return p.execInTx(ctx, p.CreateTableStmt(newTablePlan(p, table, columns)))
+}
+
+func (p *postgres) AlterTable(ctx context.Context, table string, columns []Column, opts MigrateOptions) error {
+	return p.execInTx(ctx, p.AlterTableStmt(diffTable(ctx, p, table, columns), opts))
+}
 
+// CreateTableStmt :
+func (p postgres) CreateTableStmt(plan *TablePlan) []string {
 	buf := new(bytes.Buffer)
-	buf.WriteString(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (", p.GetTable(table)))
-	for _, c := range columns {
-		for _, ss := range p.GetSchema(c) {
-			buf.WriteString(fmt.Sprintf("%s %s,",
-				p.Quote(ss.Name),
-				p.DataType(ss)))
-
-			if ss.IsIndexed {
-				idx := fmt.Sprintf("%s_%s_%s", table, ss.Name, "Idx")
-				stmt := fmt.Sprintf("CREATE INDEX %s ON %s (%s);",
-					p.Quote(idx), p.GetTable(table), p.Quote(ss.Name))
-				idxs = append(idxs, stmt)
-			}
-		}
+	buf.WriteString(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (", p.GetTable(plan.Table)))
+	for _, c := range plan.AddedColumns {
+		buf.WriteString(fmt.Sprintf("%s %s,", p.Quote(c.Name), p.DataType(c.Schema)))
 	}
 	buf.WriteString(fmt.Sprintf("PRIMARY KEY (%s)", p.Quote(pkColumn)))
 	buf.WriteString(");")
-	log.Println(buf.String())
-	if _, err := tx.Exec(buf.String()); err != nil {
-		return err
-	}
+	return append([]string{buf.String()}, p.createIndexStmts(plan)...)
+}
 
-	for _, idx := range idxs {
-		if _, err := tx.Exec(idx); err != nil {
-			return err
+// AlterTableStmt : changed column, orphan column and index are only applied when it's enabled in options
+func (p postgres) AlterTableStmt(plan *TablePlan, opts MigrateOptions) []string {
+	actions := make([]string, 0)
+	for _, c := range plan.AddedColumns {
+		actions = append(actions, fmt.Sprintf("ADD COLUMN %s %s", p.Quote(c.Name), p.DataType(c.Schema)))
+	}
+	for _, c := range plan.ChangedColumns {
+		if !opts.AlterTypes {
+			break
+		}
+		prefix := fmt.Sprintf("ALTER COLUMN %s", p.Quote(c.Name))
+		actions = append(actions, fmt.Sprintf("%s TYPE %s USING %s::%s",
+			prefix, c.DataType, p.Quote(c.Name), c.DataType))
+		if !c.IsNullable {
+			actions = append(actions, prefix+" SET NOT NULL")
+			if !c.IsOmitEmpty() {
+				actions = append(actions, fmt.Sprintf("%s SET DEFAULT %s",
+					prefix, p.ToString(c.DefaultValue)))
+			}
+		}
+	}
+	if opts.DropColumns {
+		for _, col := range plan.DroppedColumns {
+			actions = append(actions, fmt.Sprintf("DROP COLUMN %s", p.Quote(col)))
 		}
 	}
 
-	return tx.Commit()
+	stmts := make([]string, 0)
+	if len(actions) > 0 {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s %s;",
+			p.GetTable(plan.Table), strings.Join(actions, ", ")))
+	}
+	stmts = append(stmts, p.createIndexStmts(plan)...)
+	if opts.DropIndexes {
+		for _, idx := range plan.DroppedIndexes {
+			stmts = append(stmts, fmt.Sprintf("DROP INDEX IF EXISTS %s;", p.Quote(idx)))
+		}
+	}
+	return stmts
 }
 
-func (p *postgres) AlterTable(ctx context.Context, table string, columns []Column, unsafe bool) error {
-	cols := newDictionary(p.GetColumns(ctx, table))
-	idxs := newDictionary(p.GetIndexes(ctx, table))
-	idxs.delete(fmt.Sprintf("%s_pkey", table))
-	buf := new(bytes.Buffer)
-	buf.WriteString(fmt.Sprintf("ALTER TABLE %s ", p.GetTable(table)))
-	for _, c := range columns {
-		for _, ss := range p.GetSchema(c) {
-			if !cols.has(ss.Name) {
-				buf.WriteString(fmt.Sprintf("ADD COLUMN %s %s", p.Quote(ss.Name), ss.DataType))
-				if !ss.IsNullable {
-					buf.WriteString(" NOT NULL")
-					if !ss.IsOmitEmpty() {
-						buf.WriteString(fmt.Sprintf(" DEFAULT %s",
-							p.ToString(ss.DefaultValue)))
-					}
-				}
-				buf.WriteString(",")
-			} else {
-				prefix := fmt.Sprintf("ALTER COLUMN %s", p.Quote(ss.Name))
-				buf.WriteString(fmt.Sprintf("%s TYPE %s", prefix, ss.DataType))
-				buf.WriteString(",")
-				if !ss.IsNullable {
-					buf.WriteString(prefix + " SET NOT NULL,")
-					if !ss.IsOmitEmpty() {
-						buf.WriteString(fmt.Sprintf("%s SET DEFAULT %s,",
-							prefix, p.ToString(ss.DefaultValue)))
-					}
-				}
-			}
-
-			if ss.IsIndexed {
-				idx := fmt.Sprintf("%s_%s_%s", table, ss.Name, "idx")
-				if idxs.has(idx) {
-					idxs.delete(idx)
-				} else {
-
-					// buf.WriteString(fmt.Sprintf(
-					// 	" CREATE INDEX %s ON (%s);",
-					// 	p.Quote(idx),
-					// 	p.Quote(ss.Name)))
-				}
-			}
-			cols.delete(ss.Name)
+func (p postgres) createIndexStmts(plan *TablePlan) []string {
+	stmts := make([]string, 0, len(plan.AddedIndexes))
+	for _, idx := range plan.AddedIndexes {
+		cols := quoteColumns(p.Quote, idx.Columns)
+		if idx.Expression != "" {
+			cols = fmt.Sprintf("(%s)", idx.Expression)
 		}
+		stmts = append(stmts, fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s);",
+			p.Quote(idx.Name), p.GetTable(plan.Table), cols))
 	}
+	return stmts
+}
 
-	for _, col := range cols.keys() {
-		buf.WriteString(fmt.Sprintf(" DROP COLUMN %s,", p.Quote(col)))
+// GetColumnTypes : return the data type of the columns in the format of `Schema.DataType`
+func (p *postgres) GetColumnTypes(ctx context.Context, table string) map[string]string {
+	types := make(map[string]string)
+	stmt := "SELECT column_name, data_type, COALESCE(character_maximum_length, 0) FROM INFORMATION_SCHEMA.columns WHERE table_schema = CURRENT_SCHEMA() AND table_name = $1;"
+	rows, err := p.db.Query(ctx, stmt, table)
+	if err != nil {
+		return types
 	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			name, t string
+			length  int
+		)
+		rows.Scan(&name, &t, &length)
+		switch t {
+		case "character varying":
+			t = fmt.Sprintf("varchar(%d)", length)
+		case "character":
+			t = fmt.Sprintf("char(%d)", length)
+		case "boolean":
+			t = "bool"
+		case "timestamp without time zone":
+			t = "timestamp"
+		}
+		types[name] = t
+	}
+	return types
+}
 
-	buf.Truncate(buf.Len() - 1)
-	buf.WriteString(";")
+// CopyFromStmt : the statement is recognised by `lib/pq` as bulk import when it's prepared in transaction
+func (p postgres) CopyFromStmt(tb string, cols []string) string {
+	return fmt.Sprintf("COPY %s (%s) FROM STDIN",
+		p.GetTable(tb), p.Quote(strings.Join(cols, p.Quote(","))))
+}
 
-	log.Println(idxs.keys())
-	return p.db.execStmt(ctx, &stmt{
-		statement: buf,
-	})
+func (p postgres) TransactionalDDL() bool {
+	return true
+}
+
+// ClassifyError : map the SQLSTATE to the kind of error
+func (p postgres) ClassifyError(err error) error {
+	code := driverSQLState(err)
+	switch {
+	case code == "23505":
+		return ErrDuplicateKey
+	case code == "40P01":
+		return ErrDeadlock
+	case code == "55P03":
+		return ErrLockTimeout
+	case code == "42703":
+		return ErrInvalidField
+	case strings.HasPrefix(code, "08"), code == "57P01", code == "57P02", code == "57P03":
+		return ErrConnection
+	case isConnectionError(err):
+		return ErrConnection
+	}
+	return nil
+}
 
-	// for _, idx := range idxs.keys() {
-	// 	buff := new(bytes.Buffer)
-	// 	buff.WriteString(fmt.Sprintf("DROP INDEX %s;", p.Quote(idx)))
-	// 	p.db.ConsoleLog(&Stmt{buff, nil, nil})
-	// 	if _, err := tx.Exec(buff.String()); err != nil {
-	// 		return err
-	// 	}
-	// }
-
-	// for _, idx := range idxs.keys() {
-	// 	stmt := fmt.Sprintf("CREATE INDEX %s ON %s ();", p.Quote(idx), p.Quote(table))
-	// 	if _, err := tx.Exec(stmt); err != nil {
-	// 		return err
-	// 	}
-	// }
+// IsRetryable : serialization failure (40001) and deadlock (40P01) are retryable
+func (p postgres) IsRetryable(err error) bool {
+	switch driverSQLState(err) {
+	case "40001", "40P01":
+		return true
+	}
+	return false
+}
+
+func (p postgres) LockMode(mode locked) string {
+	switch mode {
+	case ReadLock:
+		return " FOR SHARE"
+	case WriteLock:
+		return " FOR UPDATE"
+	}
+	return ""
 }
 
 func (p *postgres) ReplaceInto(ctx context.Context, src, dst string) error {
@@ -0,0 +1,11 @@
+package goloquent
+
+import "testing"
+
+func TestPostgresOnConflictUpdate(t *testing.T) {
+	str := new(postgres).OnConflictUpdate("User", []string{"Name", "UpdatedAt"})
+	expected := `ON CONFLICT ("$Key") DO UPDATE SET "Name" = EXCLUDED."Name","UpdatedAt" = EXCLUDED."UpdatedAt"`
+	if str != expected {
+		t.Fatalf("Unexpected on conflict statement, %s", str)
+	}
+}
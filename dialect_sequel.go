@@ -7,9 +7,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
+
+	"cloud.google.com/go/datastore"
 )
 
 func checkMultiPtr(v reflect.Value) (isPtr bool, t reflect.Type) {
@@ -93,15 +96,59 @@ func (s *sequel) Bind(uint) string {
 }
 
 func (s *sequel) SplitJSON(name string) string {
+	return s.splitJSON("", name)
+}
+
+// splitJSON : the column is qualified by the table when it's not empty
+func (s *sequel) splitJSON(table, name string) string {
 	paths := strings.SplitN(name, ">", 2)
 	if len(paths) <= 1 {
-		return s.Quote(paths[0])
+		return qualifyColumn(s.Quote, table, paths[0])
 	}
 	return fmt.Sprintf("%s->>%q",
-		s.Quote(strings.TrimSpace(paths[0])),
+		qualifyColumn(s.Quote, table, strings.TrimSpace(paths[0])),
 		fmt.Sprintf("$.%s", strings.TrimSpace(paths[1])))
 }
 
+// Aggregate : the aggregate function of the field, `COUNT(*)` when the field is empty
+func (s sequel) Aggregate(fn, field string) string {
+	if field == "" {
+		return fn + "(*)"
+	}
+	return fmt.Sprintf("%s(%s)", fn, s.SplitJSON(field))
+}
+
+// FullKey : the simple primary key is prefixed with the kind on the last path, eg: `Parent,'a'/1` become `Parent,'a'/User,1`
+func (s sequel) FullKey(column, kind string) string {
+	last := fmt.Sprintf("SUBSTRING_INDEX(%s,'/',-1)", column)
+	return fmt.Sprintf("CONCAT(LEFT(%s,LENGTH(%s)-LENGTH(%s)),'%s,',%s)",
+		column, column, last, strings.Replace(kind, "'", "''", -1), last)
+}
+
+// FieldPosition : the position of the column value in the list, zero when it's not in the list
+func (s sequel) FieldPosition(column string, numOfValues int) string {
+	return fmt.Sprintf("FIELD(%s%s)", column, strings.Repeat(","+variable, numOfValues))
+}
+
+// qualifyColumn : the quoted column is prefixed with the quoted table when the table is not empty
+func qualifyColumn(quote func(string) string, table, column string) string {
+	if table == "" {
+		return quote(column)
+	}
+	return quote(table) + "." + quote(column)
+}
+
+// caseFieldPosition : `FIELD` is emulated with `CASE WHEN` for the dialect which doesn't has it
+func caseFieldPosition(column string, numOfValues int) string {
+	buf := new(strings.Builder)
+	buf.WriteString("CASE " + column)
+	for i := 1; i <= numOfValues; i++ {
+		buf.WriteString(fmt.Sprintf(" WHEN %s THEN %d", variable, i))
+	}
+	buf.WriteString(" ELSE 0 END")
+	return buf.String()
+}
+
 func (s sequel) JSONMarshal(v interface{}) (b json.RawMessage) {
 	switch vi := v.(type) {
 	case json.RawMessage:
@@ -124,7 +171,7 @@ func (s sequel) FilterJSON(f Filter) (string, []interface{}, error) {
 	if vv == nil {
 		vv = json.RawMessage("null")
 	}
-	name := s.SplitJSON(f.Field())
+	name := s.splitJSON(f.Table(), f.Field())
 	buf, args := new(bytes.Buffer), make([]interface{}, 0)
 	switch f.operator {
 	case Equal:
@@ -199,6 +246,32 @@ func (s sequel) FilterJSON(f Filter) (string, []interface{}, error) {
 	return buf.String(), args, nil
 }
 
+// geoPoint : the spatial point is extracted from the json of `datastore.GeoPoint`,
+// so no extra column is required by the geo filter
+func (s sequel) geoPoint(col string) string {
+	return fmt.Sprintf("POINT(JSON_EXTRACT(%s, '$.longitude'), JSON_EXTRACT(%s, '$.latitude'))", col, col)
+}
+
+// FilterGeo : filter with the spatial point of `datastore.GeoPoint`
+func (s sequel) FilterGeo(f Filter) (string, []interface{}, error) {
+	col := s.geoPoint(qualifyColumn(s.Quote, f.Table(), f.Field()))
+	switch vi := f.value.(type) {
+	case geoRadius:
+		return fmt.Sprintf("ST_Distance_Sphere(%s, POINT(%s, %s)) <= %s", col, variable, variable, variable),
+			[]interface{}{vi.point.Lng, vi.point.Lat, vi.distance}, nil
+	case geoBox:
+		str, args := filterGeoBox(fmt.Sprintf("ST_Y(%s)", col), fmt.Sprintf("ST_X(%s)", col), vi)
+		return str, args, nil
+	}
+	return "", nil, fmt.Errorf("unsupported operator")
+}
+
+// GeoDistance :
+func (s sequel) GeoDistance(field string, p datastore.GeoPoint) (string, []interface{}) {
+	return fmt.Sprintf("ST_Distance_Sphere(%s, POINT(%s, %s))", s.geoPoint(s.Quote(field)), variable, variable),
+		[]interface{}{p.Lng, p.Lat}
+}
+
 func (s *sequel) Value(it interface{}) string {
 	var str string
 	switch vi := it.(type) {
@@ -222,6 +295,10 @@ func (s *sequel) Value(it interface{}) string {
 func (s *sequel) DataType(sc Schema) string {
 	buf := new(bytes.Buffer)
 	buf.WriteString(sc.DataType)
+	if sc.Generated != "" {
+		buf.WriteString(fmt.Sprintf(" AS (%s) STORED", sc.Generated))
+		return buf.String()
+	}
 	if sc.IsUnsigned {
 		buf.WriteString(" UNSIGNED")
 	}
@@ -297,6 +374,9 @@ func (s *sequel) GetSchema(c Column) []Schema {
 	}
 
 	switch t {
+	case typeOfGeoPoint:
+		sc.DefaultValue = OmitDefault(nil)
+		sc.DataType = "json"
 	case typeOfJSONRawMessage:
 		sc.DefaultValue = OmitDefault(nil)
 		sc.DataType = "json"
@@ -386,7 +466,26 @@ func (s *sequel) GetSchema(c Column) []Schema {
 		}
 	}
 
-	return []Schema{sc}
+	schemas := []Schema{sc}
+	if sc.DataType == "json" {
+		// json path is indexed through the generated column
+		for _, p := range f.JSONIndexes() {
+			schemas = append(schemas, Schema{
+				Name:         jsonIndexColumn(sc.Name, p),
+				DataType:     fmt.Sprintf("varchar(%d)", f.JSONIndexSize()),
+				DefaultValue: OmitDefault(nil),
+				IsNullable:   true,
+				IsIndexed:    true,
+				Generated:    s.SplitJSON(jsonIndexField(sc.Name, p)),
+			})
+		}
+	}
+	return schemas
+}
+
+// GetJSONIndexes : json path is indexed by the generated column of `GetSchema`
+func (s *sequel) GetJSONIndexes(table string, c Column) []IndexPlan {
+	return nil
 }
 
 // GetColumns :
@@ -413,6 +512,30 @@ func (s *sequel) GetIndexes(ctx context.Context, table string) (idxs []string) {
 	return
 }
 
+// GetColumnTypes : return the data type of the columns, the display width of integer
+// and the `unsigned` attribute is excluded so it's comparable with `Schema.DataType`
+func (s *sequel) GetColumnTypes(ctx context.Context, table string) map[string]string {
+	types := make(map[string]string)
+	stmt := "SELECT COLUMN_NAME, COLUMN_TYPE FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?;"
+	rows, err := s.db.Query(ctx, stmt, s.CurrentDB(ctx), table)
+	if err != nil {
+		return types
+	}
+	defer rows.Close()
+	intRgx := regexp.MustCompile(`^(tinyint|smallint|mediumint|int|bigint)\(\d+\)`)
+	for rows.Next() {
+		var name, t string
+		rows.Scan(&name, &t)
+		t = strings.ToLower(t)
+		t = strings.TrimSpace(strings.NewReplacer(" unsigned", "", " zerofill", "").Replace(t))
+		if t == "tinyint(1)" {
+			t = "boolean"
+		}
+		types[name] = intRgx.ReplaceAllString(t, "$1")
+	}
+	return types
+}
+
 func (s *sequel) HasTable(ctx context.Context, table string) bool {
 	var count int
 	s.db.QueryRow(ctx, "SELECT count(*) FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?", s.CurrentDB(ctx), table).Scan(&count)
@@ -440,7 +563,15 @@ func (s *sequel) CreateTable(context.Context, string, []Column) error {
 	return nil
 }
 
-func (s *sequel) AlterTable(context.Context, string, []Column, bool) error {
+func (s *sequel) AlterTable(context.Context, string, []Column, MigrateOptions) error {
+	return nil
+}
+
+func (s *sequel) CreateTableStmt(*TablePlan) []string {
+	return nil
+}
+
+func (s *sequel) AlterTableStmt(*TablePlan, MigrateOptions) []string {
 	return nil
 }
 
@@ -448,6 +579,70 @@ func (s sequel) UpdateWithLimit() bool {
 	return false
 }
 
+// NullSafeEqual : the equal operator which treat `NULL` as comparable value
+func (s sequel) NullSafeEqual() string {
+	return "<=>"
+}
+
+// MaxBindParameters : the placeholder of prepared statement is limited to 65535
+func (s sequel) MaxBindParameters() int {
+	return 65535
+}
+
+// CopyFromStmt : `COPY FROM` is not supported
+func (s sequel) CopyFromStmt(tb string, cols []string) string {
+	return ""
+}
+
 func (s sequel) ReplaceInto(ctx context.Context, src, dst string) error {
 	return nil
 }
+
+func (s sequel) TruncateTable(ctx context.Context, table string) error {
+	buf := new(bytes.Buffer)
+	buf.WriteString(fmt.Sprintf("TRUNCATE TABLE %s;", s.GetTable(table)))
+	return s.db.execStmt(ctx, &stmt{
+		statement: buf,
+	})
+}
+
+func (s sequel) LockMode(mode locked) string {
+	switch mode {
+	case ReadLock:
+		return " LOCK IN SHARE MODE"
+	case WriteLock:
+		return " FOR UPDATE"
+	}
+	return ""
+}
+
+func (s sequel) TransactionalDDL() bool {
+	return false
+}
+
+// ClassifyError : map the mysql error number to the kind of error
+func (s sequel) ClassifyError(err error) error {
+	n, _ := driverErrorCode(err, "Number")
+	switch n {
+	case 1062, 1586:
+		return ErrDuplicateKey
+	case 1213:
+		return ErrDeadlock
+	case 1205:
+		return ErrLockTimeout
+	case 1054:
+		return ErrInvalidField
+	case 1040, 1053, 2002, 2003, 2006, 2013:
+		return ErrConnection
+	}
+	if isConnectionError(err) {
+		return ErrConnection
+	}
+	return nil
+}
+
+// IsRetryable : deadlock (error 1213) is retryable as the transaction is rollback by mysql
+func (s sequel) IsRetryable(err error) bool {
+	n, isOk := driverErrorCode(err, "Number")
+	return isOk && n == 1213
+}
@@ -0,0 +1,564 @@
+package goloquent
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"cloud.google.com/go/datastore"
+)
+
+type sqlite struct {
+	sequel
+}
+
+// minimum version which support `ON CONFLICT ... DO UPDATE` and `pragma_table_info`
+const minSQLiteVersion = "3.24"
+
+var _ Dialect = new(sqlite)
+
+func init() {
+	RegisterDialect("sqlite", new(sqlite))
+}
+
+// sqliteDriver will return the first registered sql driver of sqlite,
+// `mattn/go-sqlite3` register as "sqlite3" while `modernc.org/sqlite` register as "sqlite"
+func sqliteDriver() string {
+	drivers := newDictionary(sql.Drivers())
+	for _, d := range []string{"sqlite3", "sqlite"} {
+		if drivers.has(d) {
+			return d
+		}
+	}
+	return "sqlite3"
+}
+
+// memoryDBSeq is the sequence of the name of shared in-memory database
+var memoryDBSeq uint64
+
+// memoryDSN : every connection of private in-memory database is a new database, so it's opened as
+// named in-memory database with shared cache, then the connections of the pool share the same database,
+// the database is dropped when the last connection of the pool is closed
+func memoryDSN(dsn string) string {
+	if dsn != "" && dsn != ":memory:" && !strings.HasPrefix(dsn, "file::memory:") {
+		return dsn
+	}
+	if strings.Contains(dsn, "cache=shared") {
+		return dsn
+	}
+	name := fmt.Sprintf("file:goloquent%d?mode=memory&cache=shared", atomic.AddUint64(&memoryDBSeq, 1))
+	if i := strings.Index(dsn, "?"); i > -1 && i < len(dsn)-1 {
+		name += "&" + dsn[i+1:]
+	}
+	return name
+}
+
+// Open : the `Database` of the config is the file path of the database,
+// in-memory database will be used if it's empty
+func (s *sqlite) Open(conf Config) (*sql.DB, error) {
+	dsn := memoryDSN(conf.Database)
+	log.Println("Connection String :", dsn)
+	client, err := sql.Open(sqliteDriver(), dsn)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// GetTable :
+func (s sqlite) GetTable(name string) string {
+	return s.Quote(name)
+}
+
+// Version :
+func (s sqlite) Version(ctx context.Context) (version string) {
+	s.db.QueryRow(ctx, "SELECT sqlite_version();").Scan(&version)
+	log.Println("SQLite version :", version)
+	if compareVersion(version, minSQLiteVersion) > 0 {
+		panic(fmt.Errorf("require at least %s version of sqlite", minSQLiteVersion))
+	}
+	return
+}
+
+// CurrentDB :
+func (s *sqlite) CurrentDB(ctx context.Context) (name string) {
+	if s.dbName != "" {
+		name = s.dbName
+		return
+	}
+
+	s.db.QueryRow(ctx, "SELECT name FROM pragma_database_list WHERE seq = 0;").Scan(&name)
+	s.dbName = name
+	return
+}
+
+// Quote :
+func (s sqlite) Quote(n string) string {
+	return fmt.Sprintf(`"%s"`, n)
+}
+
+// Bind :
+func (s sqlite) Bind(uint) string {
+	return "?"
+}
+
+// splitJSON will return the column and the json path of the field, the column is qualified by the table when
+// it's not empty, eg: `Address>Region.Code` will become `"Address"` and `'$.Region.Code'`
+func (s sqlite) splitJSON(table, name string) (string, string) {
+	paths := strings.SplitN(name, ">", 2)
+	if len(paths) <= 1 {
+		return qualifyColumn(s.Quote, table, strings.TrimSpace(paths[0])), s.Value("$")
+	}
+	return qualifyColumn(s.Quote, table, strings.TrimSpace(paths[0])),
+		s.Value(fmt.Sprintf("$.%s", strings.TrimSpace(paths[1])))
+}
+
+// SplitJSON :
+func (s sqlite) SplitJSON(name string) string {
+	col, path := s.splitJSON("", name)
+	return fmt.Sprintf("json_extract(%s, %s)", col, path)
+}
+
+// Aggregate :
+func (s sqlite) Aggregate(fn, field string) string {
+	if field == "" {
+		return fn + "(*)"
+	}
+	if !strings.Contains(field, ">") {
+		return fmt.Sprintf("%s(%s)", fn, s.Quote(field))
+	}
+	return fmt.Sprintf("%s(%s)", fn, s.SplitJSON(field))
+}
+
+// FullKey : the parent path is the key trimmed until the last `/`
+func (s sqlite) FullKey(column, kind string) string {
+	parent := fmt.Sprintf("rtrim(%s,replace(%s,'/',''))", column, column)
+	return fmt.Sprintf("%s||'%s,'||substr(%s,length(%s)+1)",
+		parent, strings.Replace(kind, "'", "''", -1), column, parent)
+}
+
+// FieldPosition : `FIELD` is emulated with `CASE WHEN`
+func (s sqlite) FieldPosition(column string, numOfValues int) string {
+	return caseFieldPosition(column, numOfValues)
+}
+
+// FilterJSON :
+func (s sqlite) FilterJSON(f Filter) (string, []interface{}, error) {
+	vv, err := f.Interface()
+	if err != nil {
+		return "", nil, err
+	}
+	col, path := s.splitJSON(f.Table(), f.Field())
+	name := fmt.Sprintf("json_extract(%s, %s)", col, path)
+	buf, args := new(bytes.Buffer), make([]interface{}, 0)
+	if vv == nil {
+		switch f.operator {
+		case Equal:
+			buf.WriteString(fmt.Sprintf("json_type(%s, %s) = 'null'", col, path))
+			return buf.String(), args, nil
+		case NotEqual:
+			buf.WriteString(fmt.Sprintf("json_type(%s, %s) <> 'null'", col, path))
+			return buf.String(), args, nil
+		}
+	}
+	if b, isOk := vv.(json.RawMessage); isOk {
+		vv = string(b)
+	}
+	switch f.operator {
+	case Equal:
+		buf.WriteString(fmt.Sprintf("(%s) = %s", name, variable))
+	case NotEqual:
+		buf.WriteString(fmt.Sprintf("(%s) <> %s", name, variable))
+	case GreaterThan:
+		buf.WriteString(fmt.Sprintf("(%s) > %s", name, variable))
+	case GreaterEqual:
+		buf.WriteString(fmt.Sprintf("(%s) >= %s", name, variable))
+	case LessThan:
+		buf.WriteString(fmt.Sprintf("(%s) < %s", name, variable))
+	case LessEqual:
+		buf.WriteString(fmt.Sprintf("(%s) <= %s", name, variable))
+	case In, NotIn:
+		x, isOk := vv.([]interface{})
+		if !isOk {
+			x = append(x, vv)
+		}
+		if len(x) <= 0 {
+			return "", nil, fmt.Errorf(`goloquent: value for "In" operator cannot be empty`)
+		}
+		op := "IN"
+		if f.operator == NotIn {
+			op = "NOT IN"
+		}
+		buf.WriteString(fmt.Sprintf("(%s) %s (%s)", name, op,
+			strings.TrimRight(strings.Repeat(variable+",", len(x)), ",")))
+		args = append(args, x...)
+		return buf.String(), args, nil
+	case ContainAny:
+		x, isOk := vv.([]interface{})
+		if !isOk {
+			x = append(x, vv)
+		}
+		if len(x) <= 0 {
+			return "", nil, fmt.Errorf(`goloquent: value for "ContainAny" operator cannot be empty`)
+		}
+		buf.WriteString(fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(%s, %s) WHERE json_each.value IN (%s))",
+			col, path, strings.TrimRight(strings.Repeat(variable+",", len(x)), ",")))
+		args = append(args, x...)
+		return buf.String(), args, nil
+	case IsType:
+		buf.WriteString(fmt.Sprintf("json_type(%s, %s) = LOWER(%s)", col, path, variable))
+	case IsObject:
+		vv = "object"
+		buf.WriteString(fmt.Sprintf("json_type(%s, %s) = %s", col, path, variable))
+	case IsArray:
+		vv = "array"
+		buf.WriteString(fmt.Sprintf("json_type(%s, %s) = %s", col, path, variable))
+	default:
+		return "", nil, fmt.Errorf("unsupported operator")
+	}
+
+	args = append(args, vv)
+	return buf.String(), args, nil
+}
+
+func (s sqlite) geoLatLng(table, field string) (string, string) {
+	col := qualifyColumn(s.Quote, table, field)
+	return fmt.Sprintf("json_extract(%s, '$.latitude')", col), fmt.Sprintf("json_extract(%s, '$.longitude')", col)
+}
+
+// FilterGeo : sqlite doesn't has trigonometric function by default,
+// so distance is approximate by equirectangular projection
+func (s sqlite) FilterGeo(f Filter) (string, []interface{}, error) {
+	lat, lng := s.geoLatLng(f.Table(), f.Field())
+	switch vi := f.value.(type) {
+	case geoRadius:
+		str, args := equirectangular(lat, lng, vi.point)
+		return fmt.Sprintf("%s <= %s", str, variable), append(args, vi.distance*vi.distance), nil
+	case geoBox:
+		str, args := filterGeoBox(lat, lng, vi)
+		return str, args, nil
+	}
+	return "", nil, fmt.Errorf("unsupported operator")
+}
+
+// GeoDistance : the result is the square of distance, which is only good for sorting
+func (s sqlite) GeoDistance(field string, p datastore.GeoPoint) (string, []interface{}) {
+	lat, lng := s.geoLatLng("", field)
+	return equirectangular(lat, lng, p)
+}
+
+// Value :
+func (s sqlite) Value(it interface{}) string {
+	var str string
+	switch vi := it.(type) {
+	case nil:
+		str = "NULL"
+	case json.RawMessage:
+		str = fmt.Sprintf(`'%s'`, escapeSingleQuote(fmt.Sprintf(`%s`, vi)))
+	case string, []byte:
+		str = fmt.Sprintf(`'%s'`, escapeSingleQuote(fmt.Sprintf(`%s`, vi)))
+	default:
+		str = fmt.Sprintf("%v", vi)
+	}
+	return str
+}
+
+// GetSchema :
+func (s sqlite) GetSchema(c Column) []Schema {
+	schemas := make([]Schema, 0)
+	for _, sc := range s.sequel.GetSchema(c) {
+		// generated column is not supported, json path is indexed by expression index instead
+		if sc.Generated != "" {
+			continue
+		}
+		schemas = append(schemas, sc)
+	}
+	for i, sc := range schemas {
+		// sqlite doesn't has charset and collation on column level
+		sc.CharSet = CharSet{}
+		if sc.DataType == "json" {
+			// column type `json` will fallback to NUMERIC affinity,
+			// we want json string always store as TEXT
+			sc.DataType = "text"
+			sc.DefaultValue = OmitDefault(nil)
+			sc.IsNullable = true
+		}
+		schemas[i] = sc
+	}
+	return schemas
+}
+
+// GetJSONIndexes :
+func (s sqlite) GetJSONIndexes(table string, c Column) []IndexPlan {
+	if s.sequel.GetSchema(c)[0].DataType != "json" {
+		return nil
+	}
+	return jsonIndexPlans(table, c, s.SplitJSON)
+}
+
+// DataType :
+func (s sqlite) DataType(sc Schema) string {
+	buf := new(bytes.Buffer)
+	buf.WriteString(sc.DataType)
+	if !sc.IsNullable {
+		buf.WriteString(" NOT NULL")
+		if !sc.IsOmitEmpty() {
+			buf.WriteString(fmt.Sprintf(" DEFAULT %s", s.ToString(sc.DefaultValue)))
+		}
+	}
+	if sc.IsUnsigned {
+		buf.WriteString(fmt.Sprintf(" CHECK (%s >= 0)", s.Quote(sc.Name)))
+	}
+	return buf.String()
+}
+
+// ToString :
+func (s sqlite) ToString(it interface{}) string {
+	var v string
+	switch vi := it.(type) {
+	case nil:
+		v = "NULL"
+	case string:
+		v = fmt.Sprintf(`'%s'`, escapeSingleQuote(vi))
+	case bool:
+		v = "0"
+		if vi {
+			v = "1"
+		}
+	case uint, uint8, uint16, uint32, uint64:
+		v = fmt.Sprintf("%d", vi)
+	case int, int8, int16, int32, int64:
+		v = fmt.Sprintf("%d", vi)
+	case float32, float64:
+		v = fmt.Sprintf("%v", vi)
+	case time.Time:
+		v = fmt.Sprintf(`'%s'`, vi.Format("2006-01-02 15:04:05"))
+	default:
+		v = fmt.Sprintf("%v", vi)
+	}
+	return v
+}
+
+// GetColumns :
+func (s *sqlite) GetColumns(ctx context.Context, table string) (columns []string) {
+	stmt := "SELECT name FROM pragma_table_info(?);"
+	rows, err := s.db.Query(ctx, stmt, table)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for i := 0; rows.Next(); i++ {
+		columns = append(columns, "")
+		rows.Scan(&columns[i])
+	}
+	return
+}
+
+// GetIndexes :
+func (s *sqlite) GetIndexes(ctx context.Context, table string) (idxs []string) {
+	stmt := "SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND name NOT LIKE 'sqlite_autoindex%';"
+	rows, err := s.db.Query(ctx, stmt, table)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for i := 0; rows.Next(); i++ {
+		idxs = append(idxs, "")
+		rows.Scan(&idxs[i])
+	}
+	return
+}
+
+// GetColumnTypes : return the declared data type of the columns
+func (s *sqlite) GetColumnTypes(ctx context.Context, table string) map[string]string {
+	types := make(map[string]string)
+	rows, err := s.db.Query(ctx, "SELECT name, type FROM pragma_table_info(?);", table)
+	if err != nil {
+		return types
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, t string
+		rows.Scan(&name, &t)
+		types[name] = strings.ToLower(t)
+	}
+	return types
+}
+
+// HasTable :
+func (s *sqlite) HasTable(ctx context.Context, table string) bool {
+	var count int
+	s.db.QueryRow(ctx, "SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?;", table).Scan(&count)
+	return count > 0
+}
+
+// HasIndex :
+func (s *sqlite) HasIndex(ctx context.Context, table, idx string) bool {
+	var count int
+	s.db.QueryRow(ctx, "SELECT count(*) FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND name = ?;", table, idx).Scan(&count)
+	return count > 0
+}
+
+// OnConflictUpdate :
+func (s sqlite) OnConflictUpdate(table string, cols []string) string {
+	buf := new(bytes.Buffer)
+	buf.WriteString(fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET ", s.Quote(pkColumn)))
+	for _, c := range cols {
+		buf.WriteString(fmt.Sprintf("%s = excluded.%s,", s.Quote(c), s.Quote(c)))
+	}
+	buf.Truncate(buf.Len() - 1)
+	return buf.String()
+}
+
+// CreateTable :
+func (s *sqlite) CreateTable(ctx context.Context, table string, columns []Column) error {
+	return execStmts(ctx, s.db, s.CreateTableStmt(newTablePlan(s, table, columns)))
+}
+
+// AlterTable :
+func (s *sqlite) AlterTable(ctx context.Context, table string, columns []Column, opts MigrateOptions) error {
+	return execStmts(ctx, s.db, s.AlterTableStmt(diffTable(ctx, s, table, columns), opts))
+}
+
+// CreateTableStmt :
+func (s sqlite) CreateTableStmt(plan *TablePlan) []string {
+	buf := new(bytes.Buffer)
+	buf.WriteString(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (", s.GetTable(plan.Table)))
+	for _, c := range plan.AddedColumns {
+		buf.WriteString(fmt.Sprintf("%s %s,", s.Quote(c.Name), s.DataType(c.Schema)))
+	}
+	buf.WriteString(fmt.Sprintf("PRIMARY KEY (%s)", s.Quote(pkColumn)))
+	buf.WriteString(");")
+	return append([]string{buf.String()}, s.createIndexStmts(plan)...)
+}
+
+// AlterTableStmt : sqlite is unable to change the data type of existing column,
+// so changed column will remain untouched even `AlterTypes` is enabled,
+// dropping column require sqlite 3.35 and above
+func (s sqlite) AlterTableStmt(plan *TablePlan, opts MigrateOptions) []string {
+	stmts := make([]string, 0)
+	// sqlite unable to drop an indexed column, so the index of dropped column is always drop
+	if opts.DropIndexes || opts.DropColumns {
+		cols := newDictionary(nil)
+		for _, col := range plan.DroppedColumns {
+			cols.add(strings.ToLower(col))
+		}
+		for _, idx := range plan.DroppedIndexes {
+			col := jsonIndexOf(plan.Table, idx)
+			if col == "" {
+				col = strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(idx, plan.Table+"_"), "_idx"))
+			}
+			if !opts.DropIndexes && !cols.has(col) {
+				continue
+			}
+			stmts = append(stmts, fmt.Sprintf("DROP INDEX IF EXISTS %s;", s.Quote(idx)))
+		}
+	}
+	if opts.DropColumns {
+		for _, col := range plan.DroppedColumns {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;",
+				s.GetTable(plan.Table), s.Quote(col)))
+		}
+	}
+	for _, c := range plan.AddedColumns {
+		sc := c.Schema
+		// sqlite unable to add a not null column without default value
+		if sc.IsOmitEmpty() {
+			sc.IsNullable = true
+		}
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;",
+			s.GetTable(plan.Table), s.Quote(sc.Name), s.DataType(sc)))
+	}
+	return append(stmts, s.createIndexStmts(plan)...)
+}
+
+func (s sqlite) createIndexStmts(plan *TablePlan) []string {
+	stmts := make([]string, 0, len(plan.AddedIndexes))
+	for _, idx := range plan.AddedIndexes {
+		cols := quoteColumns(s.Quote, idx.Columns)
+		if idx.Expression != "" {
+			cols = idx.Expression
+		}
+		stmts = append(stmts, fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s);",
+			s.Quote(idx.Name), s.GetTable(plan.Table), cols))
+	}
+	return stmts
+}
+
+// UpdateWithLimit :
+func (s sqlite) UpdateWithLimit() bool {
+	return false
+}
+
+// NullSafeEqual :
+func (s sqlite) NullSafeEqual() string {
+	return "IS"
+}
+
+// MaxBindParameters : the default `SQLITE_MAX_VARIABLE_NUMBER` of sqlite prior to 3.32.0
+func (s sqlite) MaxBindParameters() int {
+	return 999
+}
+
+// ReplaceInto :
+func (s sqlite) ReplaceInto(ctx context.Context, src, dst string) error {
+	src, dst = s.GetTable(src), s.GetTable(dst)
+	buf := new(bytes.Buffer)
+	buf.WriteString("REPLACE INTO ")
+	buf.WriteString(dst + " ")
+	buf.WriteString("SELECT * FROM ")
+	buf.WriteString(src)
+	buf.WriteString(";")
+	return s.db.execStmt(ctx, &stmt{
+		statement: buf,
+	})
+}
+
+// TruncateTable :
+func (s sqlite) TruncateTable(ctx context.Context, table string) error {
+	buf := new(bytes.Buffer)
+	buf.WriteString(fmt.Sprintf("DELETE FROM %s;", s.GetTable(table)))
+	return s.db.execStmt(ctx, &stmt{
+		statement: buf,
+	})
+}
+
+// TransactionalDDL :
+func (s sqlite) TransactionalDDL() bool {
+	return true
+}
+
+// ClassifyError : map the result code of sqlite to the kind of error
+func (s sqlite) ClassifyError(err error) error {
+	code, _ := driverErrorCode(err, "Code")
+	ext, _ := driverErrorCode(err, "ExtendedCode")
+	switch {
+	// SQLITE_CONSTRAINT_PRIMARYKEY and SQLITE_CONSTRAINT_UNIQUE
+	case ext == 1555 || ext == 2067:
+		return ErrDuplicateKey
+	case code == 5 || code == 6:
+		return ErrLockTimeout
+	case code == 1 && strings.Contains(err.Error(), "no such column"):
+		return ErrInvalidField
+	case isConnectionError(err):
+		return ErrConnection
+	}
+	return nil
+}
+
+// IsRetryable : database is busy (SQLITE_BUSY) or table is locked (SQLITE_LOCKED)
+func (s sqlite) IsRetryable(err error) bool {
+	n, isOk := driverErrorCode(err, "Code")
+	return isOk && (n == 5 || n == 6)
+}
+
+// LockMode : sqlite lock the whole database on write, row lock is not supported
+func (s sqlite) LockMode(locked) string {
+	return ""
+}
@@ -0,0 +1,26 @@
+package goloquent
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSQLiteMemoryDSN(t *testing.T) {
+	for _, dsn := range []string{"", ":memory:", "file::memory:", "file::memory:?_fk=1"} {
+		str := memoryDSN(dsn)
+		if !strings.Contains(str, "mode=memory&cache=shared") {
+			t.Fatalf("Unexpected dsn of %q, %s", dsn, str)
+		}
+		if strings.Contains(dsn, "_fk=1") && !strings.HasSuffix(str, "&_fk=1") {
+			t.Fatalf("Missing parameter of %q, %s", dsn, str)
+		}
+	}
+	if memoryDSN("file::memory:") == memoryDSN("file::memory:") {
+		t.Fatal("Expected new database on every open")
+	}
+	for _, dsn := range []string{"test.db", "file:test.db?mode=ro", "file::memory:?cache=shared"} {
+		if str := memoryDSN(dsn); str != dsn {
+			t.Fatalf("Unexpected dsn of %q, %s", dsn, str)
+		}
+	}
+}
@@ -56,6 +56,18 @@ type entity struct {
 
 // TODO: check primary key must present
 func newEntity(it interface{}) (*entity, error) {
+	e, err := parseEntity(it)
+	if err != nil {
+		return nil, err
+	}
+	if _, hasKey := e.fields[keyFieldName]; !hasKey {
+		return nil, fmt.Errorf("goloquent: entity %v doesn't has primary key property", e.typeOf)
+	}
+	return e, nil
+}
+
+// parseEntity : same as `newEntity` but the primary key is not required, eg: the model of aggregate projection
+func parseEntity(it interface{}) (*entity, error) {
 	v := reflect.ValueOf(it)
 	if v.Kind() != reflect.Ptr {
 		return nil, fmt.Errorf("goloquent: model is not addressable")
@@ -91,10 +103,6 @@ func newEntity(it interface{}) (*entity, error) {
 		fields[c.Name()] = c
 	}
 
-	if _, hasKey := fields[keyFieldName]; !hasKey {
-		return nil, fmt.Errorf("goloquent: entity %v doesn't has primary key property", t)
-	}
-
 	return &entity{
 		name:       t.Name(),
 		typeOf:     t,
@@ -0,0 +1,47 @@
+package goloquent
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// Error : the driver error classified by dialect, use `errors.Is` to check the kind of error (eg: `ErrDuplicateKey`)
+// and `errors.As` to retrieve the driver error
+type Error struct {
+	Kind error
+	Err  error
+}
+
+// Error :
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v, %v", e.Kind, e.Err)
+}
+
+// Unwrap :
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is :
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
+
+// isConnectionError : the connection error which is not specific to driver
+func isConnectionError(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.As(err, &netErr)
+}
+
+// wrapError will classify the driver error by dialect
+func (c Client) wrapError(err error) error {
+	if kind := c.dialect.ClassifyError(err); kind != nil {
+		return &Error{Kind: kind, Err: err}
+	}
+	return fmt.Errorf("goloquent: %w", err)
+}
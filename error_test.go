@@ -0,0 +1,35 @@
+package goloquent
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestDialectClassifyError(t *testing.T) {
+	c := Client{dialect: new(mysql)}
+	err := c.wrapError(&testMySQLError{1062, "Duplicate entry 'a' for key 'PRIMARY'"})
+	if !errors.Is(err, ErrDuplicateKey) {
+		t.Fatal(fmt.Sprintf("Expected duplicate key error, but end up with %v", err))
+	}
+	var driverErr *testMySQLError
+	if !errors.As(err, &driverErr) || driverErr.Number != 1062 {
+		t.Fatal(fmt.Sprintf("Expected driver error is preserved, but end up with %v", err))
+	}
+	if err := c.wrapError(errors.New("unknown")); errors.Is(err, ErrDuplicateKey) {
+		t.Fatal(fmt.Sprintf("Expected unclassified error, but end up with %v", err))
+	}
+
+	c = Client{dialect: new(postgres)}
+	for code, kind := range map[string]error{
+		"23505": ErrDuplicateKey,
+		"40P01": ErrDeadlock,
+		"55P03": ErrLockTimeout,
+		"42703": ErrInvalidField,
+		"08006": ErrConnection,
+	} {
+		if err := c.wrapError(&testPQError{code}); !errors.Is(err, kind) {
+			t.Fatal(fmt.Sprintf("Expected %v on SQLSTATE %s, but end up with %v", kind, code, err))
+		}
+	}
+}
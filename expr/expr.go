@@ -1,6 +1,10 @@
 package expr
 
-import "reflect"
+import (
+	"reflect"
+	"regexp"
+	"strings"
+)
 
 // F :
 type F struct {
@@ -36,3 +40,96 @@ func Field(name string, vals interface{}) (f F) {
 	}
 	return
 }
+
+// Aggregate : the aggregate function of the projection, eg: `COUNT(*) AS Count`
+type Aggregate struct {
+	Func  string
+	Field string
+	Alias string
+}
+
+var aliasRegexp = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+func aggregate(fn, field string) Aggregate {
+	name := strings.Title(strings.ToLower(fn))
+	if field != "*" {
+		name += aliasRegexp.ReplaceAllString(field, "")
+	}
+	return Aggregate{Func: fn, Field: field, Alias: name}
+}
+
+// As : rename the alias of the aggregate, the default alias is the function name follow by the field, eg: `SumAmount`
+func (a Aggregate) As(alias string) Aggregate {
+	a.Alias = alias
+	return a
+}
+
+// Count : use `*` to count all the records
+func Count(field string) Aggregate {
+	return aggregate("COUNT", field)
+}
+
+// Sum :
+func Sum(field string) Aggregate {
+	return aggregate("SUM", field)
+}
+
+// Avg :
+func Avg(field string) Aggregate {
+	return aggregate("AVG", field)
+}
+
+// Min :
+func Min(field string) Aggregate {
+	return aggregate("MIN", field)
+}
+
+// Max :
+func Max(field string) Aggregate {
+	return aggregate("MAX", field)
+}
+
+// Column : the column of the table is used as value, eg: `Where("UpdatedAt", ">", expr.Col("CreatedAt"))`
+type Column struct {
+	Name string
+}
+
+// Col :
+func Col(name string) Column {
+	return Column{Name: name}
+}
+
+// Arithmetic : the value of the column is computed by the operator, eg: `Balance + 10`
+type Arithmetic struct {
+	Name     string
+	Operator string
+	Value    interface{}
+}
+
+// Inc : increase the column by the value, use negative value to decrease,
+// eg: `Update(ctx, map[string]interface{}{"Balance": expr.Inc("Balance", 10)})`
+func Inc(name string, value interface{}) Arithmetic {
+	return Arithmetic{Name: name, Operator: "+", Value: value}
+}
+
+// RawExpr : the raw statement with bindings
+type RawExpr struct {
+	Query string
+	Args  []interface{}
+}
+
+// Raw : the `?` of the query is the placeholder of the bindings, eg: `expr.Raw("LENGTH(?)", "abc")`
+func Raw(query string, args ...interface{}) RawExpr {
+	return RawExpr{Query: query, Args: args}
+}
+
+// Function : the sql function, the argument is bound as value unless it's an expression, eg: `expr.Col("Name")`
+type Function struct {
+	Name string
+	Args []interface{}
+}
+
+// Coalesce : the first non null value of the arguments, eg: `expr.Coalesce(expr.Col("NickName"), expr.Col("Name"), "")`
+func Coalesce(args ...interface{}) Function {
+	return Function{Name: "COALESCE", Args: args}
+}
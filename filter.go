@@ -15,6 +15,16 @@ type Filter struct {
 	value    interface{}
 	isJSON   bool
 	raw      string
+	// children is the nested filters of group, they are joined with OR when `isAny`
+	children []Filter
+	isAny    bool
+	// table is the qualifier of the field, eg: the json and geo filter of join
+	table string
+}
+
+// IsGroup :
+func (f Filter) IsGroup() bool {
+	return f.children != nil
 }
 
 // Field :
@@ -22,6 +32,11 @@ func (f Filter) Field() string {
 	return f.field
 }
 
+// Table : the table which qualify the field, it's empty unless the query has join
+func (f Filter) Table() string {
+	return f.table
+}
+
 // IsJSON :
 func (f Filter) IsJSON() bool {
 	return f.isJSON
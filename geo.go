@@ -0,0 +1,133 @@
+package goloquent
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"cloud.google.com/go/datastore"
+	"github.com/RevenueMonster/goloquent/expr"
+)
+
+// mean radius of earth in meter
+const earthRadius = 6371000
+
+type geoRadius struct {
+	point    datastore.GeoPoint
+	distance float64
+}
+
+type geoBox struct {
+	sw datastore.GeoPoint
+	ne datastore.GeoPoint
+}
+
+type geoSort struct {
+	field     string
+	point     datastore.GeoPoint
+	direction expr.Direction
+}
+
+// IsGeo :
+func (f Filter) IsGeo() bool {
+	return f.operator == WithinRadius || f.operator == WithinBox
+}
+
+// filterGeoBox will render the bounding box condition with the latitude and longitude expression,
+// box which cross the antimeridian (south west longitude greater than north east) is supported
+func filterGeoBox(lat, lng string, b geoBox) (string, []interface{}) {
+	buf := new(strings.Builder)
+	buf.WriteString(fmt.Sprintf("(%s BETWEEN %s AND %s AND ", lat, variable, variable))
+	args := []interface{}{b.sw.Lat, b.ne.Lat}
+	if b.sw.Lng > b.ne.Lng {
+		buf.WriteString(fmt.Sprintf("(%s >= %s OR %s <= %s))", lng, variable, lng, variable))
+	} else {
+		buf.WriteString(fmt.Sprintf("%s BETWEEN %s AND %s)", lng, variable, variable))
+	}
+	return buf.String(), append(args, b.sw.Lng, b.ne.Lng)
+}
+
+// haversine will render the great circle distance in meter with the latitude and longitude expression
+func haversine(lat, lng string, p datastore.GeoPoint) (string, []interface{}) {
+	return fmt.Sprintf("(%d * 2 * ASIN(SQRT(POWER(SIN(RADIANS(%s - %s) / 2), 2) + "+
+			"COS(RADIANS(%s)) * COS(RADIANS(%s)) * POWER(SIN(RADIANS(%s - %s) / 2), 2))))",
+			earthRadius, lat, variable, variable, lat, lng, variable),
+		[]interface{}{p.Lat, p.Lat, p.Lng}
+}
+
+// equirectangular will render the square of distance in meter with equirectangular projection,
+// it only require arithmetic operator and it's accurate enough for short distance
+func equirectangular(lat, lng string, p datastore.GeoPoint) (string, []interface{}) {
+	meterPerDegree := math.Pi * earthRadius / 180
+	kLat := meterPerDegree * meterPerDegree
+	kLng := kLat * math.Pow(math.Cos(p.Lat*math.Pi/180), 2)
+	return fmt.Sprintf("((%s - %s) * (%s - %s) * %s + (%s - %s) * (%s - %s) * %s)",
+			lat, variable, lat, variable, variable,
+			lng, variable, lng, variable, variable),
+		[]interface{}{p.Lat, p.Lat, kLat, p.Lng, p.Lng, kLng}
+}
+
+func checkGeoPoint(p datastore.GeoPoint) error {
+	if !p.Valid() {
+		return fmt.Errorf("goloquent: invalid geo point %v", p)
+	}
+	return nil
+}
+
+// WhereWithinRadius : filter the `datastore.GeoPoint` field which is within the distance (in meter) of the point
+func (q *Query) WhereWithinRadius(field string, p datastore.GeoPoint, meters float64) *Query {
+	q = q.clone()
+	if err := checkGeoPoint(p); err != nil {
+		q.errs = append(q.errs, err)
+		return q
+	}
+	if meters < 0 {
+		q.errs = append(q.errs, fmt.Errorf("goloquent: radius cannot be negative, %v", meters))
+		return q
+	}
+	q.filters = append(q.filters, Filter{
+		field:    field,
+		operator: WithinRadius,
+		value:    geoRadius{p, meters},
+	})
+	return q
+}
+
+// WhereWithinBox : filter the `datastore.GeoPoint` field which is within the bounding box
+// of south west and north east point
+func (q *Query) WhereWithinBox(field string, sw, ne datastore.GeoPoint) *Query {
+	q = q.clone()
+	for _, p := range []datastore.GeoPoint{sw, ne} {
+		if err := checkGeoPoint(p); err != nil {
+			q.errs = append(q.errs, err)
+			return q
+		}
+	}
+	if sw.Lat > ne.Lat {
+		q.errs = append(q.errs, fmt.Errorf("goloquent: south west latitude is greater than north east, %v, %v", sw, ne))
+		return q
+	}
+	q.filters = append(q.filters, Filter{
+		field:    field,
+		operator: WithinBox,
+		value:    geoBox{sw, ne},
+	})
+	return q
+}
+
+// OrderByDistance : sort by the distance between the `datastore.GeoPoint` field and the point,
+// prefix the field with `-` to sort in descending order
+func (q *Query) OrderByDistance(field string, p datastore.GeoPoint) *Query {
+	q = q.clone()
+	if err := checkGeoPoint(p); err != nil {
+		q.errs = append(q.errs, err)
+		return q
+	}
+	sort := geoSort{field: strings.TrimSpace(field), point: p, direction: expr.Ascending}
+	if strings.HasPrefix(sort.field, "-") {
+		sort.field = strings.TrimSpace(sort.field[1:])
+		sort.direction = expr.Descending
+	}
+	q.orders = append(q.orders, sort)
+	return q
+}
@@ -0,0 +1,41 @@
+package goloquent
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+)
+
+func TestFilterGeoBox(t *testing.T) {
+	box := geoBox{datastore.GeoPoint{Lat: 1, Lng: 103}, datastore.GeoPoint{Lat: 2, Lng: 104}}
+	str, args := filterGeoBox("lat", "lng", box)
+	if !strings.Contains(str, "lng BETWEEN") {
+		t.Fatalf("Unexpected geo box statement, %s", str)
+	}
+	if !reflect.DeepEqual(args, []interface{}{float64(1), float64(2), float64(103), float64(104)}) {
+		t.Fatalf("Unexpected geo box arguments, %v", args)
+	}
+
+	// cross antimeridian
+	box = geoBox{datastore.GeoPoint{Lat: -20, Lng: 170}, datastore.GeoPoint{Lat: -10, Lng: -170}}
+	str, _ = filterGeoBox("lat", "lng", box)
+	if !strings.Contains(str, "(lng >= ?? OR lng <= ??)") {
+		t.Fatalf("Unexpected geo box statement, %s", str)
+	}
+}
+
+func TestGeoQueryError(t *testing.T) {
+	q := newQuery(new(DB))
+	invalid := datastore.GeoPoint{Lat: 100}
+	q.WhereWithinRadius("Location", invalid, 10)
+	q.WhereWithinBox("Location", invalid, invalid)
+	q.OrderByDistance("Location", invalid)
+	if len(q.errs) > 0 {
+		t.Fatalf("Original query is mutated, %v", q.errs)
+	}
+	if err := q.WhereWithinRadius("Location", invalid, 10).getError(); err == nil {
+		t.Fatal("Expected error on invalid geo point")
+	}
+}
@@ -0,0 +1,103 @@
+package goloquent
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// ScopeFunc : return the query with the scope filters, eg: `q.WhereEqual("MerchantID", merchantID)`
+type ScopeFunc func(ctx context.Context, q *Query) *Query
+
+// Scoper : the global scopes of the model keyed by the scope name, the scopes are applied on
+// every query, update and delete of the model unless it's excluded by `WithoutScope`
+type Scoper interface {
+	Scopes() map[string]ScopeFunc
+}
+
+var (
+	scopeMutex sync.RWMutex
+	scopes     = make(map[string]map[string]ScopeFunc)
+)
+
+// RegisterScope : register the global scope of the table, the scopes of the `Scoper` model
+// are preferred, the registered scopes are applied even the model is unknown
+func RegisterScope(table, name string, fn ScopeFunc) {
+	scopeMutex.Lock()
+	defer scopeMutex.Unlock()
+	if table == "" || name == "" {
+		panic(fmt.Errorf("goloquent: scope table and name cannot be empty"))
+	}
+	if fn == nil {
+		panic(fmt.Errorf("goloquent: scope %q of table %q has no handler", name, table))
+	}
+	if _, isOk := scopes[table]; !isOk {
+		scopes[table] = make(map[string]ScopeFunc)
+	}
+	scopes[table][name] = fn
+}
+
+// UnregisterScope : remove the registered global scope of the table
+func UnregisterScope(table, name string) {
+	scopeMutex.Lock()
+	defer scopeMutex.Unlock()
+	delete(scopes[table], name)
+}
+
+// globalScopes : the registered scopes of the table, overwritten by the scopes of the model,
+// the model is resolved from the registered model of the table when it's nil, see `RegisterModel`
+func globalScopes(table string, t reflect.Type) map[string]ScopeFunc {
+	if t == nil {
+		t = modelOf(table)
+	}
+	result := make(map[string]ScopeFunc)
+	scopeMutex.RLock()
+	for name, fn := range scopes[table] {
+		result[name] = fn
+	}
+	scopeMutex.RUnlock()
+	if t != nil && t.Kind() == reflect.Struct {
+		if x, isOk := reflect.New(t).Interface().(Scoper); isOk {
+			for name, fn := range x.Scopes() {
+				result[name] = fn
+			}
+		}
+	}
+	return result
+}
+
+// applyGlobalScopes : append the filters of the global scopes which are not excluded
+func (b *builder) applyGlobalScopes(ctx context.Context, query scope, table string, t reflect.Type) (scope, error) {
+	if query.noGlobalScope {
+		return query, nil
+	}
+	fns := globalScopes(table, t)
+	if len(fns) == 0 {
+		return query, nil
+	}
+	excludes := newDictionary(query.withoutScopes)
+	names := make([]string, 0, len(fns))
+	for name := range fns {
+		if excludes.has(name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	filters := append(make([]Filter, 0, len(query.filters)), query.filters...)
+	for _, name := range names {
+		q := fns[name](ctx, newQuery(b.db))
+		if q == nil {
+			continue
+		}
+		if err := q.getError(); err != nil {
+			return query, fmt.Errorf("goloquent: invalid scope %q, %w", name, err)
+		}
+		filters = append(filters, q.filters...)
+	}
+	query.filters = filters
+	return query, nil
+}
@@ -8,4 +8,5 @@ require (
 	github.com/bxcodec/faker v2.0.1+incompatible
 	github.com/go-sql-driver/mysql v1.6.0
 	github.com/lib/pq v1.10.3
+	github.com/mattn/go-sqlite3 v1.14.16
 )
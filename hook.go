@@ -0,0 +1,96 @@
+package goloquent
+
+import (
+	"context"
+	"reflect"
+)
+
+// BeforeCreator : invoked before the entity is inserted by `Create` and `Upsert`
+type BeforeCreator interface {
+	BeforeCreate(context.Context, *DB) error
+}
+
+// AfterCreator : invoked after the entity is inserted by `Create` and `Upsert`
+type AfterCreator interface {
+	AfterCreate(context.Context, *DB) error
+}
+
+// BeforeUpdater : invoked before the entity is updated by `Save`
+type BeforeUpdater interface {
+	BeforeUpdate(context.Context, *DB) error
+}
+
+// AfterUpdater : invoked after the entity is updated by `Save`
+type AfterUpdater interface {
+	AfterUpdate(context.Context, *DB) error
+}
+
+// BeforeDeleter : invoked before the entity is deleted by `Delete` and `Destroy`
+type BeforeDeleter interface {
+	BeforeDelete(context.Context, *DB) error
+}
+
+// AfterDeleter : invoked after the entity is deleted by `Delete` and `Destroy`
+type AfterDeleter interface {
+	AfterDelete(context.Context, *DB) error
+}
+
+type hook int
+
+const (
+	hookBeforeCreate hook = iota
+	hookAfterCreate
+	hookBeforeUpdate
+	hookAfterUpdate
+	hookBeforeDelete
+	hookAfterDelete
+)
+
+// runHook : the `db` is the transaction handle when it's executed inside transaction
+func runHook(ctx context.Context, db *DB, it interface{}, h hook) error {
+	switch h {
+	case hookBeforeCreate:
+		if x, isOk := it.(BeforeCreator); isOk {
+			return x.BeforeCreate(ctx, db)
+		}
+	case hookAfterCreate:
+		if x, isOk := it.(AfterCreator); isOk {
+			return x.AfterCreate(ctx, db)
+		}
+	case hookBeforeUpdate:
+		if x, isOk := it.(BeforeUpdater); isOk {
+			return x.BeforeUpdate(ctx, db)
+		}
+	case hookAfterUpdate:
+		if x, isOk := it.(AfterUpdater); isOk {
+			return x.AfterUpdate(ctx, db)
+		}
+	case hookBeforeDelete:
+		if x, isOk := it.(BeforeDeleter); isOk {
+			return x.BeforeDelete(ctx, db)
+		}
+	case hookAfterDelete:
+		if x, isOk := it.(AfterDeleter); isOk {
+			return x.AfterDelete(ctx, db)
+		}
+	}
+	return nil
+}
+
+// runHooks : run the hook on every entity of the slice
+func runHooks(ctx context.Context, db *DB, v reflect.Value, h hook) error {
+	v = reflect.Indirect(v)
+	for i := 0; i < v.Len(); i++ {
+		f := v.Index(i)
+		if f.Kind() != reflect.Ptr {
+			f = f.Addr()
+		}
+		if f.IsNil() {
+			continue
+		}
+		if err := runHook(ctx, db, f.Interface(), h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
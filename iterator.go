@@ -3,6 +3,7 @@ package goloquent
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"reflect"
@@ -69,6 +70,24 @@ func toByte(v interface{}) []byte {
 	return b
 }
 
+// read : read the current row of `rows` into the result of the position
+func (it *Iterator) read(rows *sql.Rows, pos int) error {
+	m := make([]interface{}, len(it.columns))
+	for j := range it.columns {
+		m[j] = &m[j]
+	}
+
+	if err := rows.Scan(m...); err != nil {
+		return err
+	}
+
+	for j, name := range it.columns {
+		it.put(pos, name, m[j])
+	}
+	it.patchKey()
+	return nil
+}
+
 func (it *Iterator) put(pos int, k string, v interface{}) error {
 	diff := pos - len(it.results) + 1
 	for i := 0; i < diff; i++ {
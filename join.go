@@ -0,0 +1,333 @@
+package goloquent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var joinOperators = map[string]bool{
+	"=": true, "!=": true, "<>": true,
+	">": true, ">=": true, "<": true, "<=": true,
+}
+
+type join struct {
+	kind    string
+	isLeft  bool
+	local   string
+	op      string
+	foreign string
+}
+
+// joinPart : the field of composite result struct which hold the model of the table
+type joinPart struct {
+	kind  string
+	index []int
+	isPtr bool
+	e     *entity
+}
+
+func (q *Query) join(kind, localField, op, foreignField string, isLeft bool) *Query {
+	q = q.clone()
+	kind = strings.TrimSpace(kind)
+	if kind == "" {
+		q.errs = append(q.errs, fmt.Errorf("goloquent: missing join table"))
+		return q
+	}
+	if !joinOperators[op] {
+		q.errs = append(q.errs, fmt.Errorf("goloquent: invalid join operator %q", op))
+		return q
+	}
+	if strings.TrimSpace(localField) == "" || strings.TrimSpace(foreignField) == "" {
+		q.errs = append(q.errs, fmt.Errorf("goloquent: missing join field of table %q", kind))
+		return q
+	}
+	joins := make([]join, len(q.joins), len(q.joins)+1)
+	copy(joins, q.joins)
+	q.joins = append(joins, join{
+		kind:    kind,
+		isLeft:  isLeft,
+		local:   localField,
+		op:      op,
+		foreign: foreignField,
+	})
+	return q
+}
+
+// Join : inner join the table `kind` on `localField op foreignField`, the local field is resolved from the
+// query table unless it's qualified with table, eg: `Order.UserKey`, the result is a composite struct which has
+// the field named after each table, eg: `struct{ Order Order; User User }`
+func (q *Query) Join(kind, localField, op, foreignField string) *Query {
+	return q.join(kind, localField, op, foreignField, false)
+}
+
+// LeftJoin : same as `Join`, the model of joined table is nil when it's a pointer field and there is no match
+func (q *Query) LeftJoin(kind, localField, op, foreignField string) *Query {
+	return q.join(kind, localField, op, foreignField, true)
+}
+
+// splitKind : the prefix of the field is the table when it's the query table or any of the joined tables,
+// eg: `User.Name`, otherwise the field belongs to `kind`
+func splitKind(query scope, kind, field string) (string, string) {
+	if i := strings.Index(field, "."); i > 0 {
+		prefix := field[:i]
+		isKind := prefix == query.table
+		for _, j := range query.joins {
+			if prefix == j.kind {
+				isKind = true
+				break
+			}
+		}
+		if isKind {
+			return prefix, field[i+1:]
+		}
+	}
+	return kind, field
+}
+
+// qualifyFilters : the json and geo filters are rendered by dialect, so the table is carried by the filter
+func qualifyFilters(query scope, kind string, filters []Filter) []Filter {
+	result := make([]Filter, len(filters))
+	for i, f := range filters {
+		if f.IsGroup() {
+			f.children = qualifyFilters(query, kind, f.children)
+		} else if f.IsJSON() || f.IsGeo() {
+			f.table, f.field = splitKind(query, kind, f.field)
+		}
+		result[i] = f
+	}
+	return result
+}
+
+// joinColumn : the field is qualified by table, see `splitKind`
+func (b *builder) joinColumn(query scope, kind, field string) (string, string, bool) {
+	kind, field = splitKind(query, kind, field)
+	isKey := false
+	switch field {
+	case keyFieldName, pkColumn:
+		field, isKey = pkColumn, true
+	}
+	return kind, b.db.dialect.Quote(kind) + "." + b.db.dialect.Quote(field), isKey
+}
+
+// joinParts : each table must have a struct field in the composite result struct
+func (b *builder) joinParts(t reflect.Type) ([]joinPart, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("goloquent: invalid join result data type : %v, it should be struct", t)
+	}
+	kinds := []string{b.query.table}
+	for _, j := range b.query.joins {
+		kinds = append(kinds, j.kind)
+	}
+	parts := make([]joinPart, 0, len(kinds))
+	for _, kind := range kinds {
+		sf, isOk := t.FieldByNameFunc(func(name string) bool {
+			return strings.EqualFold(name, kind)
+		})
+		if !isOk {
+			return nil, fmt.Errorf("goloquent: join result %v doesn't has field of table %q", t, kind)
+		}
+		ft, isPtr := sf.Type, false
+		if ft.Kind() == reflect.Ptr {
+			ft, isPtr = ft.Elem(), true
+		}
+		if ft.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("goloquent: invalid data type of join field %q : %v, it should be struct", sf.Name, sf.Type)
+		}
+		e, err := newEntity(reflect.New(ft).Interface())
+		if err != nil {
+			return nil, err
+		}
+		e.setName(kind)
+		parts = append(parts, joinPart{kind: kind, index: sf.Index, isPtr: isPtr, e: e})
+	}
+	return parts, nil
+}
+
+// joinOn : the simple primary key is stored without the kind, so it's expanded when compare with the key property,
+// the soft delete and global scopes of the joined table are applied on the `ON` clause
+func (b *builder) joinOn(ctx context.Context, query scope, j join, e *entity) (string, []interface{}, error) {
+	lk, local, isLocalKey := b.joinColumn(query, query.table, j.local)
+	fk, foreign, isForeignKey := b.joinColumn(query, j.kind, j.foreign)
+	if isPkSimple && isLocalKey != isForeignKey {
+		if isLocalKey {
+			local = b.db.dialect.FullKey(local, lk)
+		} else {
+			foreign = b.db.dialect.FullKey(foreign, fk)
+		}
+	}
+	op := j.op
+	if op == "!=" {
+		op = "<>"
+	}
+	on := fmt.Sprintf("%s %s %s", local, op, foreign)
+	if e.hasSoftDelete() && !query.noScope {
+		on += fmt.Sprintf(" AND %s.%s IS NULL",
+			b.db.dialect.Quote(j.kind), b.db.dialect.Quote(softDeleteColumn))
+	}
+
+	// the fields of the scopes belong to the joined table
+	js, err := b.applyGlobalScopes(ctx, scope{
+		table:         j.kind,
+		noGlobalScope: query.noGlobalScope,
+		withoutScopes: query.withoutScopes,
+	}, j.kind, e.typeOf)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(js.filters) == 0 {
+		return on, nil, nil
+	}
+	wheres, args, err := b.buildFilters(qualifyFilters(js, j.kind, js.filters), func(f Filter) (string, bool) {
+		_, name, isKey := b.joinColumn(js, j.kind, f.Field())
+		return name, isKey
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return on + " AND " + strings.Join(wheres, " AND "), args, nil
+}
+
+func (b *builder) joinCommand(ctx context.Context, parts []joinPart) (*stmt, error) {
+	query := b.query
+	if !query.noResolution {
+		query = query.append(extractResolution(ctx))
+	}
+
+	cols := make([]string, 0)
+	for _, p := range parts {
+		for _, c := range p.e.Columns() {
+			cols = append(cols, fmt.Sprintf("%s.%s AS %s", b.db.dialect.Quote(p.kind),
+				b.db.dialect.Quote(c), b.db.dialect.Quote(p.kind+"."+c)))
+		}
+	}
+
+	main := parts[0]
+	buf := new(bytes.Buffer)
+	buf.WriteString("SELECT " + strings.Join(cols, ","))
+	buf.WriteString(fmt.Sprintf(" FROM %s AS %s",
+		b.db.dialect.GetTable(main.kind), b.db.dialect.Quote(main.kind)))
+	args := make([]interface{}, 0)
+	for i, j := range query.joins {
+		clause := "INNER JOIN"
+		if j.isLeft {
+			clause = "LEFT JOIN"
+		}
+		on, vals, err := b.joinOn(ctx, query, j, parts[i+1].e)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(fmt.Sprintf(" %s %s AS %s ON %s", clause,
+			b.db.dialect.GetTable(j.kind), b.db.dialect.Quote(j.kind), on))
+		args = append(args, vals...)
+	}
+
+	if main.e.hasSoftDelete() {
+		query = softDeleteScope(query)
+	}
+	query, err := b.applyGlobalScopes(ctx, query, main.kind, main.e.typeOf)
+	if err != nil {
+		return nil, err
+	}
+	cmd, err := b.buildStmt(query)
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteString(cmd.string())
+	buf.WriteString(b.db.dialect.LockMode(query.lockMode))
+	buf.WriteString(";")
+
+	return &stmt{
+		statement: buf,
+		arguments: append(args, cmd.arguments...),
+	}, nil
+}
+
+// scanJoin : the result columns are prefixed by table, each model is decoded from its own columns
+func (b *builder) scanJoin(ctx context.Context, it *Iterator, parts []joinPart, v reflect.Value) error {
+	row := it.results[it.position]
+	for _, p := range parts {
+		prefix := p.kind + "."
+		l := make(map[string][]byte)
+		for k, vv := range row {
+			if strings.HasPrefix(k, prefix) {
+				l[strings.TrimPrefix(k, prefix)] = vv
+			}
+		}
+		// no matching record of left join
+		if len(l[pkColumn]) == 0 {
+			continue
+		}
+		sub := &Iterator{table: p.kind, results: []map[string][]byte{l}}
+		sub.patchKey()
+		vi := reflect.New(p.e.typeOf)
+		if _, err := sub.scan(ctx, vi.Interface()); err != nil {
+			return err
+		}
+		if !p.isPtr {
+			vi = vi.Elem()
+		}
+		v.FieldByIndex(p.index).Set(vi)
+	}
+	return nil
+}
+
+func (b *builder) getJoin(ctx context.Context, model interface{}, mustExist bool) error {
+	if b.query.table == "" {
+		return fmt.Errorf("goloquent: unable to perform join without table name")
+	}
+	v := reflect.ValueOf(model)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("goloquent: model is not addressable")
+	}
+	v = v.Elem()
+
+	isMulti, isPtr, t := false, false, v.Type()
+	switch t.Kind() {
+	case reflect.Slice:
+		isMulti = true
+		isPtr, t = checkMultiPtr(v)
+	}
+	parts, err := b.joinParts(t)
+	if err != nil {
+		return err
+	}
+	cmd, err := b.joinCommand(ctx, parts)
+	if err != nil {
+		return err
+	}
+	it, err := b.run(ctx, b.query.table, cmd)
+	if err != nil {
+		return err
+	}
+
+	if !isMulti {
+		if mustExist && it.Count() == 0 {
+			return ErrNoSuchEntity
+		}
+		vi := reflect.New(t).Elem()
+		if it.First() != nil {
+			if err := b.scanJoin(ctx, it, parts, vi); err != nil {
+				return err
+			}
+		}
+		v.Set(vi)
+		return nil
+	}
+
+	vv := reflect.MakeSlice(v.Type(), 0, int(it.Count()))
+	for it.Next() {
+		vi := reflect.New(t)
+		if err := b.scanJoin(ctx, it, parts, vi.Elem()); err != nil {
+			return err
+		}
+		if !isPtr {
+			vi = vi.Elem()
+		}
+		vv = reflect.Append(vv, vi)
+	}
+	v.Set(vv)
+	return nil
+}
@@ -0,0 +1,51 @@
+package goloquent
+
+import (
+	"regexp"
+	"strings"
+)
+
+var jsonPathRegexp = regexp.MustCompile(`^\$(\.[A-Za-z_][A-Za-z0-9_]*)+$`)
+
+// isValidJSONPath : only the object member path is supported, eg: `$.address.country`
+func isValidJSONPath(path string) bool {
+	return jsonPathRegexp.MatchString(path)
+}
+
+// jsonIndexField : convert the json path to the field name of json filter, eg: `Address>Region.Code`,
+// so the indexed expression is exactly the same as the expression of `WhereJSON`
+func jsonIndexField(name, path string) string {
+	return name + ">" + strings.TrimPrefix(path, "$.")
+}
+
+// jsonIndexColumn : the name of generated column (or index) of the json path, eg: `Address$Region.Code`
+func jsonIndexColumn(name, path string) string {
+	return name + "$" + strings.TrimPrefix(path, "$.")
+}
+
+// jsonIndexPlans : the expression index of the json paths declared in `jsonindex` tag
+func jsonIndexPlans(table string, c Column, expr func(field string) string) []IndexPlan {
+	idxs := make([]IndexPlan, 0)
+	for _, p := range c.field.JSONIndexes() {
+		idxs = append(idxs, IndexPlan{
+			Name:       indexName(table, jsonIndexColumn(c.Name(), p)),
+			Columns:    []string{c.Name()},
+			Expression: expr(jsonIndexField(c.Name(), p)),
+		})
+	}
+	return idxs
+}
+
+// jsonIndexOf : return the column of the json path index, it return empty if it's not a json path index
+func jsonIndexOf(table, idx string) string {
+	prefix, suffix := strings.ToLower(table+"_"), "_idx"
+	idx = strings.ToLower(idx)
+	if !strings.HasPrefix(idx, prefix) || !strings.HasSuffix(idx, suffix) {
+		return ""
+	}
+	col := strings.TrimSuffix(strings.TrimPrefix(idx, prefix), suffix)
+	if i := strings.Index(col, "$"); i > 0 {
+		return col[:i]
+	}
+	return ""
+}
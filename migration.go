@@ -0,0 +1,210 @@
+package goloquent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/datastore"
+)
+
+const migrationTable = "goloquent_migrations"
+
+// MigrationHandler :
+type MigrationHandler func(context.Context, *DB) error
+
+// Migration :
+type Migration struct {
+	Name string
+	Up   MigrationHandler
+	Down MigrationHandler
+}
+
+// MigrationState :
+type MigrationState struct {
+	Name            string
+	Batch           int
+	IsApplied       bool
+	IsRegistered    bool
+	AppliedDateTime time.Time
+}
+
+// migrationRecord is the bookkeeping row of `goloquent_migrations`
+type migrationRecord struct {
+	Key             *datastore.Key `goloquent:"__key__"`
+	Name            string
+	Batch           int
+	AppliedDateTime time.Time
+}
+
+var (
+	migrationMutex sync.Mutex
+	migrations     = make(map[string]Migration)
+)
+
+// RegisterMigration : register a versioned migration, migrations are applied in the order of their name,
+// so it's recommended to prefix the name with timestamp, eg: `20210101000000_create_user`
+func RegisterMigration(name string, up, down MigrationHandler) {
+	migrationMutex.Lock()
+	defer migrationMutex.Unlock()
+	if name == "" {
+		panic(fmt.Errorf("goloquent: migration name cannot be empty"))
+	}
+	if up == nil {
+		panic(fmt.Errorf("goloquent: migration %q has no up handler", name))
+	}
+	if _, isExist := migrations[name]; isExist {
+		panic(fmt.Errorf("goloquent: duplicate migration %q", name))
+	}
+	migrations[name] = Migration{name, up, down}
+}
+
+// getMigrations : return all the registered migrations sort by name
+func getMigrations() []Migration {
+	migrationMutex.Lock()
+	defer migrationMutex.Unlock()
+	arr := make([]Migration, 0, len(migrations))
+	for _, m := range migrations {
+		arr = append(arr, m)
+	}
+	sort.Slice(arr, func(i, j int) bool {
+		return arr[i].Name < arr[j].Name
+	})
+	return arr
+}
+
+func (db *DB) appliedMigrations(ctx context.Context) ([]migrationRecord, error) {
+	if err := db.Table(migrationTable).Migrate(ctx, new(migrationRecord)); err != nil {
+		return nil, err
+	}
+	records := make([]migrationRecord, 0)
+	if err := db.Table(migrationTable).
+		OrderBy("Batch", "Name").
+		Get(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// runMigration will execute the migration handler and record it,
+// both are executed in a transaction when the dialect support transactional DDL
+func (db *DB) runMigration(ctx context.Context, cb func(*DB) error) error {
+	if !db.dialect.TransactionalDDL() {
+		return cb(db)
+	}
+	return db.RunInTransactionContext(ctx, nil, cb)
+}
+
+// MigrateUp : apply all the pending registered migrations as a new batch
+func (db *DB) MigrateUp(ctx context.Context) error {
+	records, err := db.appliedMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	batch := 0
+	applied := make(map[string]bool)
+	for _, r := range records {
+		applied[r.Name] = true
+		if r.Batch > batch {
+			batch = r.Batch
+		}
+	}
+	batch++
+
+	for _, m := range getMigrations() {
+		if applied[m.Name] {
+			continue
+		}
+		m := m
+		if err := db.runMigration(ctx, func(txn *DB) error {
+			if err := m.Up(ctx, txn); err != nil {
+				return fmt.Errorf("goloquent: migration %q failed, %w", m.Name, err)
+			}
+			return txn.Table(migrationTable).Create(ctx, &migrationRecord{
+				Key:             datastore.NameKey(migrationTable, m.Name, nil),
+				Name:            m.Name,
+				Batch:           batch,
+				AppliedDateTime: time.Now().UTC(),
+			})
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MigrateDown : rollback the last n applied migrations
+func (db *DB) MigrateDown(ctx context.Context, n int) error {
+	records, err := db.appliedMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	registered := make(map[string]Migration)
+	for _, m := range getMigrations() {
+		registered[m.Name] = m
+	}
+
+	for i := len(records) - 1; i >= 0 && n > 0; i-- {
+		r := records[i]
+		m, isOk := registered[r.Name]
+		if !isOk {
+			return fmt.Errorf("goloquent: migration %q is not registered", r.Name)
+		}
+		if m.Down == nil {
+			return fmt.Errorf("goloquent: migration %q has no down handler", r.Name)
+		}
+		if err := db.runMigration(ctx, func(txn *DB) error {
+			if err := m.Down(ctx, txn); err != nil {
+				return fmt.Errorf("goloquent: rollback migration %q failed, %w", m.Name, err)
+			}
+			return txn.Table(migrationTable).
+				WhereEqual(keyFieldName, r.Key).
+				Flush(ctx)
+		}); err != nil {
+			return err
+		}
+		n--
+	}
+	return nil
+}
+
+// MigrationStatus : return the state of registered and applied migrations
+func (db *DB) MigrationStatus(ctx context.Context) ([]MigrationState, error) {
+	records, err := db.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	states := make([]MigrationState, 0)
+	applied := make(map[string]migrationRecord)
+	for _, r := range records {
+		applied[r.Name] = r
+	}
+	for _, m := range getMigrations() {
+		st := MigrationState{Name: m.Name, IsRegistered: true}
+		if r, isOk := applied[m.Name]; isOk {
+			st.Batch = r.Batch
+			st.IsApplied = true
+			st.AppliedDateTime = r.AppliedDateTime
+			delete(applied, m.Name)
+		}
+		states = append(states, st)
+	}
+	// applied migrations which no longer registered
+	for _, r := range records {
+		if _, isOk := applied[r.Name]; !isOk {
+			continue
+		}
+		states = append(states, MigrationState{
+			Name:            r.Name,
+			Batch:           r.Batch,
+			IsApplied:       true,
+			AppliedDateTime: r.AppliedDateTime,
+		})
+	}
+	sort.SliceStable(states, func(i, j int) bool {
+		return states[i].Name < states[j].Name
+	})
+	return states, nil
+}
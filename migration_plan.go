@@ -0,0 +1,237 @@
+package goloquent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MigrateOptions : by default migration only create table, add column and add index,
+// the options below are unsafe as it might cause data loss
+type MigrateOptions struct {
+	// DropColumns will drop the columns which no longer exists in the model
+	DropColumns bool
+	// AlterTypes will change the data type of column when it's different from the model
+	AlterTypes bool
+	// DropIndexes will drop the index which no longer declared in the model
+	DropIndexes bool
+}
+
+// checkMigrateModels will reject the `MigrateOptions` which is misplaced as model
+func checkMigrateModels(models []interface{}) error {
+	for _, m := range models {
+		switch m.(type) {
+		case MigrateOptions, *MigrateOptions:
+			return fmt.Errorf("goloquent: `MigrateOptions` is not a model, use `MigrateWithOptions` instead")
+		}
+	}
+	return nil
+}
+
+// MigrationPlan :
+type MigrationPlan struct {
+	Tables []*TablePlan
+}
+
+// HasChanges :
+func (p *MigrationPlan) HasChanges() bool {
+	for _, t := range p.Tables {
+		if t.HasChanges() {
+			return true
+		}
+	}
+	return false
+}
+
+// Statements : return all the planned statements of every table
+func (p *MigrationPlan) Statements() []string {
+	stmts := make([]string, 0)
+	for _, t := range p.Tables {
+		stmts = append(stmts, t.Statements...)
+	}
+	return stmts
+}
+
+// ColumnPlan :
+type ColumnPlan struct {
+	Schema
+	// PrevDataType is the data type of the column in database, only applicable on changed column
+	PrevDataType string
+	// After is the column which placed before this column, empty if it's the first column
+	After string
+}
+
+// IndexPlan :
+type IndexPlan struct {
+	Name    string
+	Columns []string
+	// Expression is the indexed expression of the columns, eg: json path index
+	Expression string
+}
+
+// TablePlan : the difference between the model and the table in database
+type TablePlan struct {
+	Table          string
+	IsNew          bool
+	AddedColumns   []ColumnPlan
+	ChangedColumns []ColumnPlan
+	// ExistingColumns are the columns of the model which exist in table with the same data type,
+	// the dialect may re-declare them to apply the nullability, default value and charset of the model
+	ExistingColumns []ColumnPlan
+	DroppedColumns  []string
+	AddedIndexes    []IndexPlan
+	DroppedIndexes  []string
+	Statements      []string
+}
+
+// HasChanges :
+func (p *TablePlan) HasChanges() bool {
+	return p.IsNew ||
+		len(p.AddedColumns) > 0 ||
+		len(p.ChangedColumns) > 0 ||
+		len(p.DroppedColumns) > 0 ||
+		len(p.AddedIndexes) > 0 ||
+		len(p.DroppedIndexes) > 0
+}
+
+func indexName(table, column string) string {
+	return fmt.Sprintf("%s_%s_%s", table, column, "idx")
+}
+
+// newTablePlan : plan for a table which doesn't exists yet
+func newTablePlan(d Dialect, table string, columns []Column) *TablePlan {
+	plan := &TablePlan{Table: table, IsNew: true}
+	after := ""
+	for _, c := range columns {
+		for _, sc := range d.GetSchema(c) {
+			plan.AddedColumns = append(plan.AddedColumns, ColumnPlan{Schema: sc, After: after})
+			if sc.IsIndexed {
+				plan.AddedIndexes = append(plan.AddedIndexes, IndexPlan{
+					Name:    indexName(table, sc.Name),
+					Columns: []string{sc.Name},
+				})
+			}
+			after = sc.Name
+		}
+		plan.AddedIndexes = append(plan.AddedIndexes, d.GetJSONIndexes(table, c)...)
+	}
+	return plan
+}
+
+// diffTable : compare the model with the existing table,
+// index name is compare case insensitively as older version of postgres dialect
+// was creating index with suffix `_Idx`
+func diffTable(ctx context.Context, d Dialect, table string, columns []Column) *TablePlan {
+	plan := &TablePlan{Table: table}
+	types := d.GetColumnTypes(ctx, table)
+	idxs := make(map[string]string)
+	for _, idx := range d.GetIndexes(ctx, table) {
+		idxs[strings.ToLower(idx)] = idx
+	}
+
+	fields := newDictionary(nil)
+	indexes := newDictionary(nil)
+	after := ""
+	for _, c := range columns {
+		for _, sc := range d.GetSchema(c) {
+			fields.add(sc.Name)
+			if t, isExist := types[sc.Name]; !isExist {
+				plan.AddedColumns = append(plan.AddedColumns, ColumnPlan{Schema: sc, After: after})
+			} else if !strings.EqualFold(t, sc.DataType) {
+				plan.ChangedColumns = append(plan.ChangedColumns, ColumnPlan{Schema: sc, PrevDataType: t, After: after})
+			} else {
+				plan.ExistingColumns = append(plan.ExistingColumns, ColumnPlan{Schema: sc, After: after})
+			}
+			if sc.IsIndexed {
+				idx := indexName(table, sc.Name)
+				indexes.add(strings.ToLower(idx))
+				if _, isExist := idxs[strings.ToLower(idx)]; !isExist {
+					plan.AddedIndexes = append(plan.AddedIndexes, IndexPlan{
+						Name:    idx,
+						Columns: []string{sc.Name},
+					})
+				}
+			}
+			after = sc.Name
+		}
+		for _, idx := range d.GetJSONIndexes(table, c) {
+			indexes.add(strings.ToLower(idx.Name))
+			if _, isExist := idxs[strings.ToLower(idx.Name)]; !isExist {
+				plan.AddedIndexes = append(plan.AddedIndexes, idx)
+			}
+		}
+	}
+
+	cols := newDictionary(nil)
+	for col := range types {
+		cols.add(strings.ToLower(col))
+		if !fields.has(col) {
+			plan.DroppedColumns = append(plan.DroppedColumns, col)
+		}
+		// only the single column index created by migration will be drop
+		idx := strings.ToLower(indexName(table, col))
+		if n, isExist := idxs[idx]; isExist && !indexes.has(idx) {
+			plan.DroppedIndexes = append(plan.DroppedIndexes, n)
+		}
+	}
+	// the json path index which is not backed by generated column
+	for idx, n := range idxs {
+		col := jsonIndexOf(table, idx)
+		if col == "" || !cols.has(col) || indexes.has(idx) ||
+			cols.has(strings.TrimSuffix(strings.TrimPrefix(idx, strings.ToLower(table+"_")), "_idx")) {
+			continue
+		}
+		plan.DroppedIndexes = append(plan.DroppedIndexes, n)
+	}
+	sort.Strings(plan.DroppedColumns)
+	sort.Strings(plan.DroppedIndexes)
+	return plan
+}
+
+func planTable(ctx context.Context, d Dialect, table string, columns []Column, opts MigrateOptions) *TablePlan {
+	if !d.HasTable(ctx, table) {
+		plan := newTablePlan(d, table, columns)
+		plan.Statements = d.CreateTableStmt(plan)
+		return plan
+	}
+	plan := diffTable(ctx, d, table, columns)
+	plan.Statements = d.AlterTableStmt(plan, opts)
+	return plan
+}
+
+func quoteColumns(quote func(string) string, cols []string) string {
+	arr := make([]string, len(cols))
+	for i, c := range cols {
+		arr[i] = quote(c)
+	}
+	return strings.Join(arr, ",")
+}
+
+func execStmts(ctx context.Context, c Client, stmts []string) error {
+	for _, s := range stmts {
+		if err := c.execStmt(ctx, &stmt{
+			statement: bytes.NewBufferString(s),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *builder) planMigration(ctx context.Context, models []interface{}, opts MigrateOptions) (*MigrationPlan, error) {
+	if err := checkMigrateModels(models); err != nil {
+		return nil, err
+	}
+	plan := new(MigrationPlan)
+	for _, m := range models {
+		e, err := newEntity(m)
+		if err != nil {
+			return nil, err
+		}
+		e.setName(b.query.table)
+		plan.Tables = append(plan.Tables, planTable(ctx, b.db.dialect, e.Name(), e.columns, opts))
+	}
+	return plan, nil
+}
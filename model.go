@@ -0,0 +1,44 @@
+package goloquent
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	modelMutex sync.RWMutex
+	models     = make(map[string]reflect.Type)
+)
+
+// RegisterModel : declare the model of the table, it's used to resolve the model when the query has no model,
+// eg: the `Scoper` of `Flush`, `Restore`, `Count` or `Update` with map, the model is never registered implicitly
+func RegisterModel(table string, model interface{}) {
+	t := reflect.TypeOf(model)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		panic(fmt.Errorf("goloquent: invalid model data type : %v, it should be struct", t))
+	}
+	if table == "" {
+		table = t.Name()
+	}
+	modelMutex.Lock()
+	defer modelMutex.Unlock()
+	models[table] = t
+}
+
+// UnregisterModel : remove the registered model of the table
+func UnregisterModel(table string) {
+	modelMutex.Lock()
+	defer modelMutex.Unlock()
+	delete(models, table)
+}
+
+// modelOf : the registered model of the table, nil when the table is not registered
+func modelOf(table string) reflect.Type {
+	modelMutex.RLock()
+	defer modelMutex.RUnlock()
+	return models[table]
+}
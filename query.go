@@ -35,6 +35,8 @@ const (
 	IsArray
 	IsType
 	MatchAgainst
+	WithinRadius
+	WithinBox
 )
 
 type sortDirection int
@@ -81,20 +83,30 @@ type group struct {
 }
 
 type scope struct {
-	table           string
-	distinctOn      []string
-	projection      []string
-	omits           []string
-	ancestors       []group
-	filters         []Filter
-	orders          []interface{}
-	limit           int32
-	offset          int32
-	errs            []error
-	noScope         bool
-	noResolution    bool
-	lockMode        locked
-	replicaResolver replicaResolver
+	table      string
+	distinctOn []string
+	projection []interface{}
+	groupBy    []string
+	havings    []Filter
+	// havingAggregates are the aggregates of `Having` which are not in projection
+	havingAggregates []expr.Aggregate
+	omits            []string
+	ancestors        []group
+	filters          []Filter
+	orders           []interface{}
+	limit            int32
+	offset           int32
+	errs             []error
+	noScope          bool
+	onlyTrashed      bool
+	noGlobalScope    bool
+	withoutScopes    []string
+	noResolution     bool
+	lockMode         locked
+	replicaResolver  replicaResolver
+	relations        []string
+	ancestorLoads    []ancestorLoad
+	joins            []join
 }
 
 func (s scope) append(s2 scope) scope {
@@ -144,10 +156,34 @@ func (q *Query) getError() error {
 	return nil
 }
 
+// projectionFields : the field names of projection, aggregates are excluded
+func (s scope) projectionFields() []string {
+	fields := make([]string, 0, len(s.projection))
+	for _, p := range s.projection {
+		if f, isOk := p.(string); isOk {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// isAggregate : the query is grouped or has aggregate projection
+func (s scope) isAggregate() bool {
+	if len(s.groupBy) > 0 {
+		return true
+	}
+	for _, p := range s.projection {
+		if _, isOk := p.(expr.Aggregate); isOk {
+			return true
+		}
+	}
+	return false
+}
+
 // Select :
 func (q *Query) Select(fields ...string) *Query {
 	q = q.clone()
-	arr := make([]string, 0, len(fields))
+	arr := make([]interface{}, 0, len(fields))
 	for _, f := range fields {
 		f := strings.TrimSpace(f)
 		if f == "" {
@@ -156,7 +192,63 @@ func (q *Query) Select(fields ...string) *Query {
 		}
 		arr = append(arr, f)
 	}
-	q.projection = append(q.projection, arr...)
+	q.projection = append(append(make([]interface{}, 0, len(q.projection)+len(arr)), q.projection...), arr...)
+	return q
+}
+
+// SelectAggregate : select the aggregate together with the projection of `Select`, eg: `SelectAggregate(expr.Count("*"))`
+func (q *Query) SelectAggregate(aggrs ...expr.Aggregate) *Query {
+	q = q.clone()
+	arr := make([]interface{}, 0, len(aggrs))
+	for _, x := range aggrs {
+		if strings.TrimSpace(x.Field) == "" || strings.TrimSpace(x.Alias) == "" {
+			q.errs = append(q.errs, fmt.Errorf("goloquent: invalid `SelectAggregate` value %v", x))
+			return q
+		}
+		arr = append(arr, x)
+	}
+	q.projection = append(append(make([]interface{}, 0, len(q.projection)+len(arr)), q.projection...), arr...)
+	return q
+}
+
+// GroupBy :
+func (q *Query) GroupBy(fields ...string) *Query {
+	q = q.clone()
+	arr := make([]string, 0, len(fields))
+	for _, f := range fields {
+		f := strings.TrimSpace(f)
+		if f == "" || f == "*" {
+			q.errs = append(q.errs, fmt.Errorf("goloquent: invalid `GroupBy` value %q", f))
+			return q
+		}
+		arr = append(arr, f)
+	}
+	q.groupBy = append(append(make([]string, 0, len(q.groupBy)+len(arr)), q.groupBy...), arr...)
+	return q
+}
+
+// Having : the field can be the alias of aggregate in projection, or the aggregate itself,
+// eg: `Having("Count", ">", 10)` or `Having(expr.Sum("Amount"), ">", 100)`
+func (q *Query) Having(field interface{}, op string, value interface{}) *Query {
+	q = q.clone()
+	name := ""
+	switch vi := field.(type) {
+	case string:
+		name = strings.TrimSpace(vi)
+	case expr.Aggregate:
+		name = vi.Alias
+		q.havingAggregates = append(append(make([]expr.Aggregate, 0, len(q.havingAggregates)+1), q.havingAggregates...), vi)
+	}
+	if name == "" {
+		q.errs = append(q.errs, fmt.Errorf("goloquent: invalid `Having` field %v", field))
+		return q
+	}
+	f, err := newFilter(name, op, value, false)
+	if err != nil {
+		q.errs = append(q.errs, err)
+		return q
+	}
+	q.havings = append(append(make([]Filter, 0, len(q.havings)+1), q.havings...), f)
 	return q
 }
 
@@ -189,7 +281,7 @@ func (q *Query) Omit(fields ...string) *Query {
 		arr = append(arr, f)
 	}
 	// Primary key cannot be omited
-	dict := newDictionary(append(q.projection, arr...))
+	dict := newDictionary(append(q.projectionFields(), arr...))
 	dict.delete(keyFieldName)
 	dict.delete(pkColumn)
 	q.omits = dict.keys()
@@ -202,6 +294,23 @@ func (q *Query) Unscoped() *Query {
 	return q
 }
 
+// OnlyTrashed : only the soft deleted records are included
+func (q *Query) OnlyTrashed() *Query {
+	q.onlyTrashed = true
+	return q
+}
+
+// WithoutScope : exclude the global scopes by name, all the global scopes are excluded when no name is given
+func (q *Query) WithoutScope(names ...string) *Query {
+	q = q.clone()
+	if len(names) == 0 {
+		q.noGlobalScope = true
+		return q
+	}
+	q.withoutScopes = append(append(make([]string, 0, len(q.withoutScopes)+len(names)), q.withoutScopes...), names...)
+	return q
+}
+
 // Unscoped :
 func (q *Query) ReplicaResolver(resolver replicaResolver) *Query {
 	q.replicaResolver = resolver
@@ -226,7 +335,10 @@ func (q *Query) Find(ctx context.Context, key *datastore.Key, model interface{})
 		return fmt.Errorf("goloquent: find action with invalid key value, %q", key)
 	}
 	q = q.Where(keyFieldName, "=", key).Limit(1)
-	return newBuilder(q, operationRead).get(ctx, model, true)
+	if err := newBuilder(q, operationRead).get(ctx, model, true); err != nil {
+		return err
+	}
+	return q.loadRelations(ctx, model)
 }
 
 // First :
@@ -239,7 +351,13 @@ func (q *Query) First(ctx context.Context, model interface{}) error {
 		return err
 	}
 	q.Limit(1)
-	return newBuilder(q, operationRead).get(ctx, model, false)
+	if len(q.joins) > 0 {
+		return newBuilder(q, operationRead).getJoin(ctx, model, false)
+	}
+	if err := newBuilder(q, operationRead).get(ctx, model, false); err != nil {
+		return err
+	}
+	return q.loadRelations(ctx, model)
 }
 
 // Get :
@@ -248,7 +366,48 @@ func (q *Query) Get(ctx context.Context, model interface{}) error {
 	if err := q.getError(); err != nil {
 		return err
 	}
-	return newBuilder(q, operationRead).getMulti(ctx, model)
+	if len(q.joins) > 0 {
+		return newBuilder(q, operationRead).getJoin(ctx, model, false)
+	}
+	if err := newBuilder(q, operationRead).getMulti(ctx, model); err != nil {
+		return err
+	}
+	return q.loadRelations(ctx, model)
+}
+
+// Rows : return the cursor of the query result which decode one row at a time,
+// the model is used to resolve the table and columns, the cursor must be closed after use
+func (q *Query) Rows(ctx context.Context, model interface{}) (*Rows, error) {
+	q = q.clone()
+	if err := q.getError(); err != nil {
+		return nil, err
+	}
+	if err := checkSinglePtr(model); err != nil {
+		return nil, err
+	}
+	if len(q.joins) > 0 {
+		return nil, fmt.Errorf("goloquent: join query is not supported by rows cursor")
+	}
+	return newBuilder(q, operationRead).rows(ctx, model)
+}
+
+// Each : decode the query result into the model one row at a time and invoke the callback,
+// the iteration is stopped when the callback return error
+func (q *Query) Each(ctx context.Context, model interface{}, cb func() error) error {
+	rows, err := q.Rows(ctx, model)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := rows.Scan(ctx, model); err != nil {
+			return err
+		}
+		if err := cb(); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
 }
 
 // Paginate :
@@ -257,6 +416,9 @@ func (q *Query) Paginate(ctx context.Context, p *Pagination, model interface{})
 	if err := q.getError(); err != nil {
 		return err
 	}
+	if len(q.joins) > 0 {
+		return fmt.Errorf("goloquent: join query is not supported by pagination")
+	}
 	q = q.clone()
 	if p.query != nil {
 		q = q.append(p.query)
@@ -275,9 +437,9 @@ func (q *Query) Paginate(ctx context.Context, p *Pagination, model interface{})
 			}
 		}
 		if !pkSortExist {
-			lastField := q.orders[len(q.orders)-1].(expr.Sort)
 			k := pkColumn
-			if lastField.Direction == expr.Descending {
+			if lastField, isOk := q.orders[len(q.orders)-1].(expr.Sort); isOk &&
+				lastField.Direction == expr.Descending {
 				k = "-" + k
 			}
 			q = q.OrderBy(k)
@@ -285,7 +447,10 @@ func (q *Query) Paginate(ctx context.Context, p *Pagination, model interface{})
 	} else {
 		q = q.OrderBy(pkColumn)
 	}
-	return newBuilder(q, operationRead).paginate(ctx, p, model)
+	if err := newBuilder(q, operationRead).paginate(ctx, p, model); err != nil {
+		return err
+	}
+	return q.loadRelations(ctx, model)
 }
 
 // Ancestor :
@@ -326,13 +491,18 @@ func (q *Query) AnyOfAncestor(ancestors ...*datastore.Key) *Query {
 	return q
 }
 
-func (q *Query) where(field, op string, value interface{}, isJSON bool) *Query {
+func newFilter(field, op string, value interface{}, isJSON bool) (Filter, error) {
 	op = strings.TrimSpace(strings.ToLower(op))
 	var optr operator
 
 	switch op {
 	case "=", "eq", "$eq", "equal":
 		optr = Equal
+	case "<=>":
+		if isJSON {
+			return Filter{}, fmt.Errorf("goloquent: invalid operator %q for json", op)
+		}
+		optr = EqualTo
 	case "!=", "<>", "ne", "$ne", "notequal", "not equal":
 		optr = NotEqual
 	case ">", "!<", "gt", "$gt":
@@ -351,22 +521,19 @@ func (q *Query) where(field, op string, value interface{}, isJSON bool) *Query {
 		optr = AnyLike
 	case "like", "$like":
 		if isJSON {
-			q.errs = append(q.errs, fmt.Errorf("goloquent: invalid operator %q for json", op))
-			return q
+			return Filter{}, fmt.Errorf("goloquent: invalid operator %q for json", op)
 		}
 		optr = Like
 	case "nlike", "!like", "$nlike":
 		if isJSON {
-			q.errs = append(q.errs, fmt.Errorf("goloquent: invalid operator %q for json", op))
-			return q
+			return Filter{}, fmt.Errorf("goloquent: invalid operator %q for json", op)
 		}
 		optr = NotLike
 	case "match":
 		optr = MatchAgainst
 	default:
 		if !isJSON {
-			q.errs = append(q.errs, fmt.Errorf("goloquent: invalid operator %q", op))
-			return q
+			return Filter{}, fmt.Errorf("goloquent: invalid operator %q", op)
 		}
 
 		switch op {
@@ -379,20 +546,94 @@ func (q *Query) where(field, op string, value interface{}, isJSON bool) *Query {
 		case "isarray":
 			optr = IsArray
 		default:
-			q.errs = append(q.errs, fmt.Errorf("goloquent: invalid operator %q for json", op))
-			return q
+			return Filter{}, fmt.Errorf("goloquent: invalid operator %q for json", op)
 		}
 	}
 
-	q.filters = append(q.filters, Filter{
+	return Filter{
 		field:    field,
 		operator: optr,
 		value:    value,
 		isJSON:   isJSON,
+	}, nil
+}
+
+func (q *Query) where(field, op string, value interface{}, isJSON bool) *Query {
+	f, err := newFilter(field, op, value, isJSON)
+	if err != nil {
+		q.errs = append(q.errs, err)
+		return q
+	}
+	q.filters = append(q.filters, f)
+	return q
+}
+
+// groupFilter : the filters of the callback are grouped, the callback should return the query with filters
+func (q *Query) groupFilter(cb func(q *Query) *Query, isAny bool) *Query {
+	q = q.clone()
+	sq := cb(newQuery(q.db))
+	if sq == nil {
+		return q
+	}
+	if err := sq.getError(); err != nil {
+		q.errs = append(q.errs, err)
+		return q
+	}
+	q.filters = append(append(make([]Filter, 0, len(q.filters)+1), q.filters...), Filter{
+		children: append(make([]Filter, 0, len(sq.filters)), sq.filters...),
+		isAny:    isAny,
 	})
 	return q
 }
 
+// WhereAny : the filters of the callback are joined with OR,
+// eg: `WhereAny(func(q *Query) *Query { return q.WhereEqual("Status", "A").WhereEqual("Status", "B") })`
+// will become `(Status = 'A' OR Status = 'B')`
+func (q *Query) WhereAny(cb func(q *Query) *Query) *Query {
+	return q.groupFilter(cb, true)
+}
+
+// WhereAll : the filters of the callback are joined with AND, it's used to nest filters inside `WhereAny`
+func (q *Query) WhereAll(cb func(q *Query) *Query) *Query {
+	return q.groupFilter(cb, false)
+}
+
+// orWhere : the filter is joined with the previous filter by OR,
+// eg: `Where(A).Where(B).OrWhere(C)` will become `A AND (B OR C)`
+func (q *Query) orWhere(field, op string, value interface{}, isJSON bool) *Query {
+	q = q.clone()
+	f, err := newFilter(field, op, value, isJSON)
+	if err != nil {
+		q.errs = append(q.errs, err)
+		return q
+	}
+	n := len(q.filters)
+	filters := append(make([]Filter, 0, n+1), q.filters...)
+	if n == 0 {
+		q.filters = append(filters, f)
+		return q
+	}
+	last := filters[n-1]
+	if last.IsGroup() && last.isAny {
+		last.children = append(append(make([]Filter, 0, len(last.children)+1), last.children...), f)
+	} else {
+		last = Filter{children: []Filter{last, f}, isAny: true}
+	}
+	filters[n-1] = last
+	q.filters = filters
+	return q
+}
+
+// OrWhere :
+func (q *Query) OrWhere(field string, op string, value interface{}) *Query {
+	return q.orWhere(field, op, value, false)
+}
+
+// OrWhereJSON :
+func (q *Query) OrWhereJSON(field, op string, v interface{}) *Query {
+	return q.orWhere(field, op, v, true)
+}
+
 // Where :
 func (q *Query) Where(field string, op string, value interface{}) *Query {
 	q = q.clone()
@@ -641,6 +882,23 @@ func (q *Query) Flush(ctx context.Context) error {
 	return newBuilder(q, operationWrite).deleteByQuery(ctx)
 }
 
+// Restore : restore the soft deleted records which match the query
+func (q *Query) Restore(ctx context.Context) error {
+	if err := q.getError(); err != nil {
+		return err
+	}
+	if q.table == "" {
+		return fmt.Errorf("goloquent: unable to perform restore without table name")
+	}
+	return newBuilder(q, operationWrite).restoreByQuery(ctx)
+}
+
+// ForceDelete : permanently delete the records which match the query, soft deleted records are included
+// unless `OnlyTrashed` is used
+func (q *Query) ForceDelete(ctx context.Context) error {
+	return q.Flush(ctx)
+}
+
 // Scan :
 func (q *Query) Scan(ctx context.Context, dest ...interface{}) error {
 	return newBuilder(q, operationRead).scan(ctx, dest...)
@@ -0,0 +1,403 @@
+package goloquent
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"cloud.google.com/go/datastore"
+)
+
+var typeOfKeys = reflect.TypeOf([]*datastore.Key(nil))
+
+// ancestorLoad : load the parent (when kind is empty) or children of the result set into dst
+type ancestorLoad struct {
+	kind string
+	dst  interface{}
+}
+
+// relation : the `*datastore.Key` or `[]*datastore.Key` field and the field to populate the entities
+type relation struct {
+	name     string
+	keyPath  []int
+	isMulti  bool
+	dstPath  []int
+	dstType  reflect.Type
+	elemType reflect.Type
+}
+
+// lookupKeyField will find the field by name (or the name in tag) follow by the dot path
+func lookupKeyField(t reflect.Type, name string) ([]int, reflect.Type, error) {
+	path := make([]int, 0)
+	ft := t
+	for _, n := range strings.Split(name, ".") {
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() != reflect.Struct {
+			return nil, nil, fmt.Errorf("goloquent: invalid relation field %q", name)
+		}
+		isFound := false
+		for i := 0; i < ft.NumField(); i++ {
+			sf := ft.Field(i)
+			if sf.Name == n || newTag(sf).name == n {
+				path = append(path, i)
+				ft = sf.Type
+				isFound = true
+				break
+			}
+		}
+		if !isFound {
+			return nil, nil, fmt.Errorf("goloquent: relation field %q not found in %v", name, t)
+		}
+	}
+	return path, ft, nil
+}
+
+func getRelation(t reflect.Type, name string) (*relation, error) {
+	keyPath, kt, err := lookupKeyField(t, name)
+	if err != nil {
+		return nil, err
+	}
+	if kt != typeOfPtrKey && kt != typeOfKeys {
+		return nil, fmt.Errorf("goloquent: relation field %q must be either *datastore.Key or []*datastore.Key", name)
+	}
+
+	r := &relation{name: name, keyPath: keyPath, isMulti: kt == typeOfKeys}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !strings.EqualFold(newTag(sf).Get("with"), name) {
+			continue
+		}
+		et := sf.Type
+		if r.isMulti {
+			if et.Kind() != reflect.Slice {
+				return nil, fmt.Errorf("goloquent: field %q of relation %q must be slice", sf.Name, name)
+			}
+			et = et.Elem()
+		}
+		if et.Kind() == reflect.Ptr {
+			et = et.Elem()
+		}
+		if et.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("goloquent: field %q of relation %q has invalid data type %v", sf.Name, name, sf.Type)
+		}
+		r.dstPath = []int{i}
+		r.dstType = sf.Type
+		r.elemType = et
+		return r, nil
+	}
+	return nil, fmt.Errorf("goloquent: missing field with tag `with=%s` in %v", name, t)
+}
+
+// relationField will traverse the struct by path, it return invalid value when there is nil pointer in between
+func relationField(v reflect.Value, path []int) reflect.Value {
+	for _, p := range path {
+		v = reflect.Indirect(v)
+		if !v.IsValid() {
+			return v
+		}
+		v = v.Field(p)
+	}
+	return v
+}
+
+// entityValues will return the addressable struct values of the model
+func entityValues(model interface{}) []reflect.Value {
+	v := reflect.Indirect(reflect.ValueOf(model))
+	values := make([]reflect.Value, 0)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			vi := v.Index(i)
+			if vi.Kind() == reflect.Ptr {
+				if vi.IsNil() {
+					continue
+				}
+				vi = vi.Elem()
+			}
+			values = append(values, vi)
+		}
+	case reflect.Struct:
+		values = append(values, v)
+	}
+	return values
+}
+
+// loadByKeys will load the entities group by kind, the entity is return in map with key string
+func (q *Query) loadByKeys(ctx context.Context, t reflect.Type, keys []*datastore.Key) (map[string]reflect.Value, error) {
+	kinds := make(map[string][]*datastore.Key)
+	kindOrders := make([]string, 0)
+	for _, k := range keys {
+		if _, isExist := kinds[k.Kind]; !isExist {
+			kindOrders = append(kindOrders, k.Kind)
+		}
+		kinds[k.Kind] = append(kinds[k.Kind], k)
+	}
+
+	codec, err := getStructCodec(reflect.New(t).Interface())
+	if err != nil {
+		return nil, err
+	}
+	pk, err := codec.findField(keyFieldName)
+	if err != nil {
+		return nil, fmt.Errorf("goloquent: relation entity %v has no primary key", t)
+	}
+
+	result := make(map[string]reflect.Value)
+	for _, kind := range kindOrders {
+		dst := reflect.New(reflect.SliceOf(reflect.PtrTo(t)))
+		if err := q.db.Table(kind).newQuery().
+			WhereIn(keyFieldName, kinds[kind]).
+			Get(ctx, dst.Interface()); err != nil {
+			return nil, err
+		}
+		dst = dst.Elem()
+		for i := 0; i < dst.Len(); i++ {
+			vi := dst.Index(i)
+			k, isOk := getFieldByIndex(vi.Elem(), pk.paths).Interface().(*datastore.Key)
+			if !isOk || k == nil {
+				continue
+			}
+			result[stringPk(k)] = vi
+		}
+	}
+	return result, nil
+}
+
+// loadRelations will eager load the relations of the model, every kind is load in one query
+func (q *Query) loadRelations(ctx context.Context, model interface{}) error {
+	values := entityValues(model)
+	if len(values) <= 0 {
+		return nil
+	}
+	if err := q.loadAncestors(ctx, values); err != nil {
+		return err
+	}
+	t := values[0].Type()
+	for _, name := range q.relations {
+		r, err := getRelation(t, name)
+		if err != nil {
+			return err
+		}
+
+		keys := make([]*datastore.Key, 0)
+		dict := newDictionary(nil)
+		collect := func(k *datastore.Key) {
+			if k == nil || k.Incomplete() || dict.has(stringPk(k)) {
+				return
+			}
+			dict.add(stringPk(k))
+			keys = append(keys, k)
+		}
+		for _, v := range values {
+			fv := relationField(v, r.keyPath)
+			if !fv.IsValid() {
+				continue
+			}
+			switch vi := fv.Interface().(type) {
+			case *datastore.Key:
+				collect(vi)
+			case []*datastore.Key:
+				for _, k := range vi {
+					collect(k)
+				}
+			}
+		}
+		// the destinations are still reset when there is nothing to load
+		entities := make(map[string]reflect.Value)
+		if len(keys) > 0 {
+			entities, err = q.loadByKeys(ctx, r.elemType, keys)
+			if err != nil {
+				return err
+			}
+		}
+		for _, v := range values {
+			r.populate(v, entities)
+		}
+	}
+	return nil
+}
+
+// toElem : convert the loaded entity (pointer) to the element type of destination
+func toElem(v reflect.Value, t reflect.Type) reflect.Value {
+	if t.Kind() == reflect.Ptr {
+		return v
+	}
+	return v.Elem()
+}
+
+// populate : the destination is reset to zero value when the referenced entity is not found,
+// so the reused struct doesn't carry the stale relation
+func (r *relation) populate(v reflect.Value, entities map[string]reflect.Value) {
+	dst := v.FieldByIndex(r.dstPath)
+	fv := relationField(v, r.keyPath)
+	if !fv.IsValid() {
+		dst.Set(reflect.Zero(r.dstType))
+		return
+	}
+	switch vi := fv.Interface().(type) {
+	case *datastore.Key:
+		if vi == nil {
+			dst.Set(reflect.Zero(r.dstType))
+			return
+		}
+		if e, isOk := entities[stringPk(vi)]; isOk {
+			dst.Set(toElem(e, r.dstType))
+		} else {
+			dst.Set(reflect.Zero(r.dstType))
+		}
+	case []*datastore.Key:
+		arr := reflect.MakeSlice(r.dstType, 0, len(vi))
+		for _, k := range vi {
+			if k == nil {
+				continue
+			}
+			if e, isOk := entities[stringPk(k)]; isOk {
+				arr = reflect.Append(arr, toElem(e, r.dstType.Elem()))
+			}
+		}
+		dst.Set(arr)
+	}
+}
+
+// With : eager load the entities referenced by the `*datastore.Key` or `[]*datastore.Key` field,
+// the entities will be populated into the field tagged with `with=FieldName`, eg:
+//
+//	type User struct {
+//		Merchant       *datastore.Key
+//		MerchantEntity *Merchant `goloquent:",with=Merchant"`
+//	}
+func (q *Query) With(fields ...string) *Query {
+	q = q.clone()
+	relations := make([]string, 0, len(fields))
+	for _, f := range fields {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			q.errs = append(q.errs, fmt.Errorf("goloquent: invalid `With` value %q", f))
+			return q
+		}
+		relations = append(relations, f)
+	}
+	q.relations = append(append(make([]string, 0, len(q.relations)+len(relations)), q.relations...), relations...)
+	return q
+}
+
+func checkMultiDst(dst interface{}) (reflect.Type, error) {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return nil, fmt.Errorf("goloquent: destination must be pointer of slice, but end up with %v", reflect.TypeOf(dst))
+	}
+	t := v.Elem().Type().Elem()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("goloquent: destination must be slice of struct, but end up with %v", reflect.TypeOf(dst))
+	}
+	return t, nil
+}
+
+// entityKeys will return the primary key of the entities
+func entityKeys(values []reflect.Value) ([]*datastore.Key, error) {
+	codec, err := getStructCodec(reflect.New(values[0].Type()).Interface())
+	if err != nil {
+		return nil, err
+	}
+	pk, err := codec.findField(keyFieldName)
+	if err != nil {
+		return nil, fmt.Errorf("goloquent: entity %v has no primary key", values[0].Type())
+	}
+	keys := make([]*datastore.Key, 0, len(values))
+	for _, v := range values {
+		k, isOk := getFieldByIndex(v, pk.paths).Interface().(*datastore.Key)
+		if !isOk || k == nil {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// loadAncestors will load the parent and children of the result set
+func (q *Query) loadAncestors(ctx context.Context, values []reflect.Value) error {
+	if len(q.ancestorLoads) <= 0 {
+		return nil
+	}
+	keys, err := entityKeys(values)
+	if err != nil {
+		return err
+	}
+	if len(keys) <= 0 {
+		return nil
+	}
+
+	for _, l := range q.ancestorLoads {
+		t, err := checkMultiDst(l.dst)
+		if err != nil {
+			return err
+		}
+		if l.kind != "" {
+			if err := q.db.Table(l.kind).newQuery().
+				AnyOfAncestor(keys...).
+				Get(ctx, l.dst); err != nil {
+				return err
+			}
+			continue
+		}
+
+		parents := make([]*datastore.Key, 0)
+		dict := newDictionary(nil)
+		for _, k := range keys {
+			if k.Parent == nil || k.Parent.Incomplete() || dict.has(stringPk(k.Parent)) {
+				continue
+			}
+			dict.add(stringPk(k.Parent))
+			parents = append(parents, k.Parent)
+		}
+		dst := reflect.ValueOf(l.dst).Elem()
+		arr := reflect.MakeSlice(dst.Type(), 0, len(parents))
+		if len(parents) > 0 {
+			entities, err := q.loadByKeys(ctx, t, parents)
+			if err != nil {
+				return err
+			}
+			for _, k := range parents {
+				if e, isOk := entities[stringPk(k)]; isOk {
+					arr = reflect.Append(arr, toElem(e, dst.Type().Elem()))
+				}
+			}
+		}
+		dst.Set(arr)
+	}
+	return nil
+}
+
+// WithParent : load the parent entities of the result set into dst, dst must be pointer of slice
+func (q *Query) WithParent(dst interface{}) *Query {
+	q = q.clone()
+	if _, err := checkMultiDst(dst); err != nil {
+		q.errs = append(q.errs, err)
+		return q
+	}
+	q.ancestorLoads = append(append(make([]ancestorLoad, 0, len(q.ancestorLoads)+1), q.ancestorLoads...), ancestorLoad{dst: dst})
+	return q
+}
+
+// WithChildren : load the descendant entities of the result set from table `kind` into dst,
+// dst must be pointer of slice
+func (q *Query) WithChildren(kind string, dst interface{}) *Query {
+	q = q.clone()
+	kind = strings.TrimSpace(kind)
+	if kind == "" {
+		q.errs = append(q.errs, fmt.Errorf("goloquent: invalid `WithChildren` kind %q", kind))
+		return q
+	}
+	if _, err := checkMultiDst(dst); err != nil {
+		q.errs = append(q.errs, err)
+		return q
+	}
+	q.ancestorLoads = append(append(make([]ancestorLoad, 0, len(q.ancestorLoads)+1), q.ancestorLoads...), ancestorLoad{kind: kind, dst: dst})
+	return q
+}
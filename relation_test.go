@@ -0,0 +1,63 @@
+package goloquent
+
+import (
+	"reflect"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+)
+
+func TestRelationPopulate(t *testing.T) {
+	type Category struct {
+		Key  *datastore.Key `goloquent:"__key__"`
+		Name string
+	}
+	type Product struct {
+		Key            *datastore.Key `goloquent:"__key__"`
+		Category       *datastore.Key
+		CategoryEntity *Category `goloquent:",with=Category"`
+	}
+
+	r, err := getRelation(reflect.TypeOf(Product{}), "Category")
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := datastore.IDKey("Category", 1, nil)
+	p := Product{Category: k, CategoryEntity: &Category{Name: "Stale"}}
+	r.populate(reflect.ValueOf(&p).Elem(), map[string]reflect.Value{})
+	if p.CategoryEntity != nil {
+		t.Fatalf("Unexpected stale relation, %v", p.CategoryEntity)
+	}
+
+	c := &Category{Key: k, Name: "Food"}
+	r.populate(reflect.ValueOf(&p).Elem(), map[string]reflect.Value{stringPk(k): reflect.ValueOf(c)})
+	if p.CategoryEntity != c {
+		t.Fatalf("Unexpected relation, %v", p.CategoryEntity)
+	}
+}
+
+func TestRelationWithIsolation(t *testing.T) {
+	q := newQuery(new(DB)).With("Merchant").With("Category").With("Order")
+	a, b := q.With("User"), q.With("Store")
+	if !reflect.DeepEqual(a.relations, []string{"Merchant", "Category", "Order", "User"}) {
+		t.Fatalf("Unexpected relations, %v", a.relations)
+	}
+	if !reflect.DeepEqual(b.relations, []string{"Merchant", "Category", "Order", "Store"}) {
+		t.Fatalf("Unexpected relations, %v", b.relations)
+	}
+}
+
+func TestRelationAncestorLoadIsolation(t *testing.T) {
+	type Merchant struct {
+		Key *datastore.Key `goloquent:"__key__"`
+	}
+	parents, children := new([]Merchant), new([]Merchant)
+	q := newQuery(new(DB)).WithParent(parents).WithParent(parents).WithParent(parents)
+	a, b := q.WithChildren("Store", children), q.WithChildren("User", children)
+	if n := len(a.ancestorLoads); n != 4 || a.ancestorLoads[3].kind != "Store" {
+		t.Fatalf("Unexpected ancestor loads, %v", a.ancestorLoads)
+	}
+	if n := len(b.ancestorLoads); n != 4 || b.ancestorLoads[3].kind != "User" {
+		t.Fatalf("Unexpected ancestor loads, %v", b.ancestorLoads)
+	}
+}
@@ -0,0 +1,92 @@
+package goloquent
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+)
+
+// RetryPolicy : the transaction callback is re-executed when it fail with retryable error (eg: deadlock)
+type RetryPolicy struct {
+	// MaxAttempts is the maximum execution of the transaction including the first attempt,
+	// retry is disabled when it's less than 2
+	MaxAttempts int
+	// Backoff return the waiting duration before the next attempt, default is exponential backoff start from 10ms
+	Backoff func(attempt int) time.Duration
+	// IsRetryable is the classifier of the error, default is classified by dialect
+	IsRetryable func(err error) bool
+}
+
+func (p RetryPolicy) shouldRetry(d Dialect, err error, attempt int) bool {
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+	if p.IsRetryable != nil {
+		return p.IsRetryable(err)
+	}
+	return d.IsRetryable(err)
+}
+
+// wait for the backoff duration, it return error when the context is done
+func (p RetryPolicy) wait(ctx context.Context, attempt int) error {
+	d := (10 * time.Millisecond) << uint(attempt-1)
+	if p.Backoff != nil {
+		d = p.Backoff(attempt)
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// SetRetryPolicy : set the retry policy of `RunInTransaction` and `RunInTransactionContext`
+func (db *DB) SetRetryPolicy(p RetryPolicy) {
+	db.client.retry = p
+}
+
+// driverErrorField will find the field of driver error (eg: `Number` of mysql error) through the error chain,
+// so the driver package is not required to be imported
+func driverErrorField(err error, name string) (reflect.Value, bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		v := reflect.Indirect(reflect.ValueOf(err))
+		if v.Kind() != reflect.Struct {
+			continue
+		}
+		if f := v.FieldByName(name); f.IsValid() {
+			return f, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// driverErrorCode : the numeric error code of driver error
+func driverErrorCode(err error, name string) (int64, bool) {
+	f, isOk := driverErrorField(err, name)
+	if !isOk {
+		return 0, false
+	}
+	switch f.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return f.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(f.Uint()), true
+	}
+	return 0, false
+}
+
+// driverSQLState : the SQLSTATE of driver error, eg: `Code` of `lib/pq` or `SQLState()` of `pgx`
+func driverSQLState(err error) string {
+	var e interface{ SQLState() string }
+	if errors.As(err, &e) {
+		return e.SQLState()
+	}
+	if f, isOk := driverErrorField(err, "Code"); isOk && f.Kind() == reflect.String {
+		return f.String()
+	}
+	return ""
+}
@@ -0,0 +1,39 @@
+package goloquent
+
+import (
+	"fmt"
+	"testing"
+)
+
+type testMySQLError struct {
+	Number  uint16
+	Message string
+}
+
+func (e *testMySQLError) Error() string {
+	return fmt.Sprintf("Error %d: %s", e.Number, e.Message)
+}
+
+type testPQError struct {
+	Code string
+}
+
+func (e testPQError) Error() string {
+	return "pq: " + e.Code
+}
+
+func TestDialectIsRetryable(t *testing.T) {
+	deadlock := fmt.Errorf("goloquent: %w", &testMySQLError{1213, "Deadlock found"})
+	if !new(mysql).IsRetryable(deadlock) {
+		t.Fatal("Expected mysql deadlock is retryable")
+	}
+	if new(mysql).IsRetryable(&testMySQLError{1062, "Duplicate entry"}) {
+		t.Fatal("Expected mysql duplicate entry is not retryable")
+	}
+	if !new(postgres).IsRetryable(fmt.Errorf("goloquent: %w", &testPQError{"40P01"})) {
+		t.Fatal("Expected postgres deadlock is retryable")
+	}
+	if new(postgres).IsRetryable(fmt.Errorf("goloquent: %v", &testPQError{"40001"})) {
+		t.Fatal("Expected unwrapped error is not retryable")
+	}
+}
@@ -0,0 +1,49 @@
+package goloquent
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Rows : the cursor of the query result, unlike `Get` only the current row is kept in memory,
+// relations of `With` are not loaded
+type Rows struct {
+	rows *sql.Rows
+	it   *Iterator
+	err  error
+}
+
+// Next : read the next row, it return false when there is no more row or error occurred
+func (r *Rows) Next() bool {
+	if r.err != nil || !r.rows.Next() {
+		return false
+	}
+	r.it.results = r.it.results[:0]
+	if err := r.it.read(r.rows, 0); err != nil {
+		r.err = err
+		return false
+	}
+	r.it.position = 0
+	return true
+}
+
+// Scan : decode the current row into the model
+func (r *Rows) Scan(ctx context.Context, model interface{}) error {
+	if r.it.position < 0 || len(r.it.results) == 0 {
+		return sql.ErrNoRows
+	}
+	return r.it.Scan(ctx, model)
+}
+
+// Err : the error occurred during iteration
+func (r *Rows) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.rows.Err()
+}
+
+// Close : close the underlying rows, it's safe to be called multiple times
+func (r *Rows) Close() error {
+	return r.rows.Close()
+}
@@ -26,6 +26,8 @@ type Schema struct {
 	IsUnsigned   bool
 	IsNullable   bool
 	IsIndexed    bool
+	// Generated is the expression of generated column, the column is not writable
+	Generated string
 	CharSet
 }
 
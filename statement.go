@@ -32,6 +32,7 @@ type Stmt struct {
 	replacer  replacer
 	startTime time.Time
 	endTime   time.Time
+	attempt   int
 	Result    sql.Result
 }
 
@@ -48,6 +49,12 @@ func (s Stmt) TimeElapse() time.Duration {
 	return s.endTime.Sub(s.startTime)
 }
 
+// Attempt : the execution count of the transaction which the statement belongs to,
+// it's zero when the statement is not executed in transaction
+func (s Stmt) Attempt() int {
+	return s.attempt
+}
+
 // Raw :
 func (s *Stmt) Raw() string {
 	buf := new(bytes.Buffer)
@@ -10,7 +10,11 @@ import (
 	"github.com/RevenueMonster/goloquent/expr"
 )
 
-var stmtRegistry *StmtRegistry
+// stmtRegistries : the statement registry of each driver, it's created from the dialect on first use
+var stmtRegistries = struct {
+	sync.Mutex
+	m map[string]*StmtRegistry
+}{m: make(map[string]*StmtRegistry)}
 
 type Writer interface {
 	io.Writer
@@ -26,9 +30,26 @@ type StmtRegistry struct {
 	kindEncoders map[reflect.Kind]writerFunc
 }
 
-func init() {
-	stmtRegistry = NewStmtRegistry()
-	stmtRegistry.SetDefaultEncoders()
+// GetStmtRegistry : the statement registry of the dialect, the custom encoder is registered per dialect,
+// eg: `r, _ := GetStmtRegistry("postgres"); r.SetTypeEncoder(reflect.TypeOf(Money{}), encodeMoney)`
+func GetStmtRegistry(driver string) (*StmtRegistry, bool) {
+	d, isValid := dialects[driver]
+	if !isValid {
+		return nil, false
+	}
+	return stmtRegistryOf(driver, d), true
+}
+
+func stmtRegistryOf(driver string, d Dialect) *StmtRegistry {
+	stmtRegistries.Lock()
+	defer stmtRegistries.Unlock()
+	r, isOk := stmtRegistries.m[driver]
+	if !isOk {
+		r = NewStmtRegistry()
+		r.setDefaultEncoders(d)
+		stmtRegistries.m[driver] = r
+	}
+	return r
 }
 
 func NewStmtRegistry() *StmtRegistry {
@@ -38,10 +59,19 @@ func NewStmtRegistry() *StmtRegistry {
 	}
 }
 
+// SetDefaultEncoders : the identifier is quoted with backtick and `FIELD` is rendered as mysql
 func (r *StmtRegistry) SetDefaultEncoders() {
-	enc := DefaultStmtEncoder{registry: defaultRegistry}
+	r.setDefaultEncoders(new(sequel))
+}
+
+func (r *StmtRegistry) setDefaultEncoders(d Dialect) {
+	enc := DefaultStmtEncoder{registry: defaultRegistry, stmtRegistry: r, dialect: d}
 	r.SetTypeEncoder(reflect.TypeOf(expr.F{}), enc.encodeField)
 	r.SetTypeEncoder(reflect.TypeOf(expr.Sort{}), enc.encodeSort)
+	r.SetTypeEncoder(reflect.TypeOf(expr.Column{}), enc.encodeColumn)
+	r.SetTypeEncoder(reflect.TypeOf(expr.Arithmetic{}), enc.encodeArithmetic)
+	r.SetTypeEncoder(reflect.TypeOf(expr.RawExpr{}), enc.encodeRaw)
+	r.SetTypeEncoder(reflect.TypeOf(expr.Function{}), enc.encodeFunction)
 	r.SetKindEncoder(reflect.String, enc.encodeString)
 }
 
@@ -57,28 +87,63 @@ func (r *StmtRegistry) SetKindEncoder(k reflect.Kind, f writerFunc) {
 	r.kindEncoders[k] = f
 }
 
+// isExpr : the value is an expression when it has type encoder, eg: `expr.Col`
+func (r *StmtRegistry) isExpr(it interface{}) bool {
+	if it == nil {
+		return false
+	}
+	r.Lock()
+	defer r.Unlock()
+	_, isOk := r.typeEncoders[reflect.TypeOf(it)]
+	return isOk
+}
+
 func (r *StmtRegistry) BuildStatement(w Writer, v reflect.Value) ([]interface{}, error) {
-	if encoder, isOk := r.typeEncoders[v.Type()]; isOk {
-		return encoder(w, v)
+	r.Lock()
+	encoder, isOk := r.typeEncoders[v.Type()]
+	if !isOk {
+		encoder, isOk = r.kindEncoders[v.Kind()]
 	}
-	if encoder, isOk := r.kindEncoders[v.Kind()]; isOk {
-		return encoder(w, v)
+	r.Unlock()
+	if !isOk {
+		return nil, fmt.Errorf("unsupported data type: %v", v)
 	}
-	return nil, fmt.Errorf("unsupported data type: %v", v)
+	return encoder(w, v)
 }
 
 type DefaultStmtEncoder struct {
-	registry *Registry
+	registry     *Registry
+	stmtRegistry *StmtRegistry
+	dialect      Dialect
+}
+
+// encodeValue : the nested expression is rendered, otherwise the value is bound
+func (enc DefaultStmtEncoder) encodeValue(w Writer, it interface{}) ([]interface{}, error) {
+	if enc.stmtRegistry != nil && enc.stmtRegistry.isExpr(it) {
+		return enc.stmtRegistry.BuildStatement(w, reflect.ValueOf(it))
+	}
+	v, err := normalizeValue(it)
+	if err != nil {
+		return nil, err
+	}
+	if v, err = interfaceToValue(v); err != nil {
+		return nil, err
+	}
+	if v, err = marshal(v); err != nil {
+		return nil, err
+	}
+	w.WriteString(variable)
+	return []interface{}{v}, nil
 }
 
 func (enc DefaultStmtEncoder) encodeString(w Writer, v reflect.Value) ([]interface{}, error) {
-	w.WriteString("`" + v.String() + "`")
+	w.WriteString(enc.dialect.Quote(v.String()))
 	return nil, nil
 }
 
 func (enc DefaultStmtEncoder) encodeSort(w Writer, v reflect.Value) ([]interface{}, error) {
 	x := v.Interface().(expr.Sort)
-	w.WriteString("`" + x.Name + "`")
+	w.WriteString(enc.dialect.Quote(x.Name))
 	if x.Direction == expr.Descending {
 		w.WriteString(" DESC")
 	}
@@ -90,19 +155,74 @@ func (enc DefaultStmtEncoder) encodeField(w Writer, v reflect.Value) ([]interfac
 	if !isOk {
 		return nil, errors.New("invalid data type")
 	}
-	w.WriteString("FIELD")
-	w.WriteByte('(')
-	w.WriteString("`" + x.Name + "`")
-	vals := make([]interface{}, 0)
+	vals := make([]interface{}, 0, len(x.Values))
 	for _, vv := range x.Values {
-		w.WriteByte(',')
-		w.WriteString(variable)
 		it, err := enc.registry.EncodeValue(vv)
 		if err != nil {
 			return nil, err
 		}
 		vals = append(vals, it)
 	}
+	w.WriteString(enc.dialect.FieldPosition(enc.dialect.Quote(x.Name), len(vals)))
+	return vals, nil
+}
+
+func (enc DefaultStmtEncoder) encodeColumn(w Writer, v reflect.Value) ([]interface{}, error) {
+	x := v.Interface().(expr.Column)
+	w.WriteString(enc.dialect.Quote(x.Name))
+	return nil, nil
+}
+
+func (enc DefaultStmtEncoder) encodeArithmetic(w Writer, v reflect.Value) ([]interface{}, error) {
+	x := v.Interface().(expr.Arithmetic)
+	switch x.Operator {
+	case "+", "-", "*", "/":
+	default:
+		return nil, fmt.Errorf("invalid arithmetic operator %q", x.Operator)
+	}
+	w.WriteString(enc.dialect.Quote(x.Name) + " " + x.Operator + " ")
+	return enc.encodeValue(w, x.Value)
+}
+
+func (enc DefaultStmtEncoder) encodeRaw(w Writer, v reflect.Value) ([]interface{}, error) {
+	x := v.Interface().(expr.RawExpr)
+	vals, i := make([]interface{}, 0, len(x.Args)), 0
+	for _, r := range x.Query {
+		if r != '?' {
+			w.WriteString(string(r))
+			continue
+		}
+		if i >= len(x.Args) {
+			return nil, errors.New("missing binding of raw expression")
+		}
+		vv, err := enc.encodeValue(w, x.Args[i])
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, vv...)
+		i++
+	}
+	if i != len(x.Args) {
+		return nil, fmt.Errorf("expected %d bindings of raw expression, got %d", i, len(x.Args))
+	}
+	return vals, nil
+}
+
+func (enc DefaultStmtEncoder) encodeFunction(w Writer, v reflect.Value) ([]interface{}, error) {
+	x := v.Interface().(expr.Function)
+	w.WriteString(x.Name)
+	w.WriteByte('(')
+	vals := make([]interface{}, 0, len(x.Args))
+	for i, arg := range x.Args {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		vv, err := enc.encodeValue(w, arg)
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, vv...)
+	}
 	w.WriteByte(')')
 	return vals, nil
 }
@@ -0,0 +1,51 @@
+package goloquent
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/RevenueMonster/goloquent/expr"
+)
+
+func TestStmtRegistryDialect(t *testing.T) {
+	mysql, _ := GetStmtRegistry("mysql")
+	postgres, _ := GetStmtRegistry("postgres")
+	field := expr.Field("Status", []string{"A", "B"})
+
+	buf := new(bytes.Buffer)
+	args, err := mysql.BuildStatement(buf, reflect.ValueOf(field))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "FIELD(`Status`,??,??)" || len(args) != 2 {
+		t.Fatalf("Unexpected mysql statement, %s", buf.String())
+	}
+
+	buf.Reset()
+	args, err = postgres.BuildStatement(buf, reflect.ValueOf(field))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != `CASE "Status" WHEN ?? THEN 1 WHEN ?? THEN 2 ELSE 0 END` || len(args) != 2 {
+		t.Fatalf("Unexpected postgres statement, %s", buf.String())
+	}
+
+	buf.Reset()
+	if _, err := postgres.BuildStatement(buf, reflect.ValueOf(expr.Sort{Name: "Age", Direction: expr.Descending})); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != `"Age" DESC` {
+		t.Fatalf("Unexpected postgres sorting, %s", buf.String())
+	}
+
+	// custom encoder is only registered to the dialect
+	type upper string
+	postgres.SetTypeEncoder(reflect.TypeOf(upper("")), func(w Writer, v reflect.Value) ([]interface{}, error) {
+		w.WriteString("UPPER(" + variable + ")")
+		return []interface{}{v.String()}, nil
+	})
+	if !postgres.isExpr(upper("a")) || mysql.isExpr(upper("a")) {
+		t.Fatal("Unexpected custom encoder of dialect")
+	}
+}
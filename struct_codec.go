@@ -212,6 +212,17 @@ func getStructCodec(it interface{}) (*StructCodec, error) {
 			case isReserveFieldName(st.name):
 				return nil, fmt.Errorf("goloquent: struct tag has reserved field name: %q", st.name)
 			}
+			for _, p := range st.JSONIndexes() {
+				if !isValidJSONPath(p) {
+					return nil, fmt.Errorf("goloquent: struct tag has invalid json index path: %q", p)
+				}
+			}
+			if st.IsVersion() && !isVersionType(ft) {
+				return nil, fmt.Errorf("goloquent: version field %q must be integer, but end up with %v", st.name, ft)
+			}
+			if (st.IsCreatedAt() || st.IsUpdatedAt()) && !isTimestampType(ft) {
+				return nil, fmt.Errorf("goloquent: timestamp field %q must be time.Time or *time.Time, but end up with %v", st.name, ft)
+			}
 
 			if ft == typeOfSoftDelete {
 				st.name = softDeleteColumn
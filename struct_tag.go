@@ -3,6 +3,7 @@ package goloquent
 import (
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -12,8 +13,6 @@ type tag struct {
 	others  map[string]string
 }
 
-// TODO: Eager loading tag
-
 func newTag(sf reflect.StructField) tag {
 	name := sf.Name
 
@@ -29,20 +28,28 @@ func newTag(sf reflect.StructField) tag {
 		"omitempty": false,
 		"unsigned":  false,
 		"longtext":  false,
+		"version":   false,
+		"createdat": false,
+		"updatedat": false,
 	}
 
 	others := make(map[string]string)
 	paths = paths[1:]
 	for _, k := range paths {
+		v := k
 		k = strings.ToLower(k)
 		if _, isValid := options[k]; isValid {
 			options[k] = true
 		} else {
-			rgx := regexp.MustCompile(`(datatype|charset|collate)\=.+`)
+			rgx := regexp.MustCompile(`(datatype|charset|collate|with|jsonindexsize|jsonindex)\=.+`)
 			if rgx.MatchString(k) {
 				rgx = regexp.MustCompile(`(\w+)=(.+)`)
 				result := rgx.FindStringSubmatch(k)
 				others[result[1]] = result[2]
+				// json path is case sensitive
+				if result[1] == "jsonindex" {
+					others[result[1]] = strings.TrimSpace(strings.SplitN(v, "=", 2)[1])
+				}
 			}
 		}
 	}
@@ -62,8 +69,33 @@ func (t tag) isPrimaryKey() bool {
 	return t.name == keyFieldName
 }
 
+// isSkip : field of eager loading is not a column as well
 func (t tag) isSkip() bool {
-	return t.name == "-"
+	return t.name == "-" || t.isRelation()
+}
+
+func (t tag) isRelation() bool {
+	return t.others["with"] != ""
+}
+
+// JSONIndexes : the json paths to index, multiple paths are separated by `|`, eg: `jsonindex=$.country|$.city`
+func (t tag) JSONIndexes() []string {
+	paths := make([]string, 0)
+	for _, p := range strings.Split(t.others["jsonindex"], "|") {
+		if p = strings.TrimSpace(p); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// JSONIndexSize : the length of the generated column of json index on MySQL, eg: `jsonindexsize=255`, default is 191
+func (t tag) JSONIndexSize() int {
+	n, err := strconv.Atoi(strings.TrimSpace(t.others["jsonindexsize"]))
+	if err != nil || n <= 0 {
+		return 191
+	}
+	return n
 }
 
 func (t tag) isFlatten() bool {
@@ -85,3 +117,18 @@ func (t tag) IsUnsigned() bool {
 func (t tag) IsLongText() bool {
 	return t.options["longtext"]
 }
+
+// IsCreatedAt : the creation timestamp which is set automatically on create
+func (t tag) IsCreatedAt() bool {
+	return t.options["createdat"]
+}
+
+// IsUpdatedAt : the modification timestamp which is set automatically on create and update
+func (t tag) IsUpdatedAt() bool {
+	return t.options["updatedat"]
+}
+
+// IsVersion : the version column of optimistic concurrency control
+func (t tag) IsVersion() bool {
+	return t.options["version"]
+}
@@ -42,3 +42,44 @@ func TestStructTagWithIndex(t *testing.T) {
 		t.Fatal("Expected tag have index, but end up with noindex")
 	}
 }
+
+func TestStructTagWithRelation(t *testing.T) {
+	type merchant struct{}
+	var i struct {
+		Merchant       string
+		MerchantEntity *merchant `goloquent:",with=Merchant"`
+	}
+	vt := reflect.ValueOf(i).Type()
+	tag := newTag(vt.Field(1))
+	if !tag.isRelation() || !tag.isSkip() {
+		t.Fatal("Expected tag is relation and skip, but end up with not")
+	}
+	if tag := newTag(vt.Field(0)); tag.isRelation() {
+		t.Fatal("Expected tag is not relation, but end up with relation")
+	}
+}
+
+func TestStructTagWithJSONIndex(t *testing.T) {
+	var i struct {
+		Address struct{} `goloquent:",jsonindex=$.PostCode| $.Region.TimeZone"`
+	}
+	vt := reflect.ValueOf(i).Type()
+	paths := newTag(vt.Field(0)).JSONIndexes()
+	if !reflect.DeepEqual(paths, []string{"$.PostCode", "$.Region.TimeZone"}) {
+		t.Fatal(fmt.Sprintf("Unexpected json index paths, %v", paths))
+	}
+}
+
+func TestStructTagWithJSONIndexSize(t *testing.T) {
+	var i struct {
+		Address struct{} `goloquent:",jsonindex=$.PostCode,jsonindexsize=255"`
+		Contact struct{} `goloquent:",jsonindex=$.Email"`
+	}
+	vt := reflect.ValueOf(i).Type()
+	if size := newTag(vt.Field(0)).JSONIndexSize(); size != 255 {
+		t.Fatal(fmt.Sprintf("Unexpected json index size, %d", size))
+	}
+	if size := newTag(vt.Field(1)).JSONIndexSize(); size != 191 {
+		t.Fatal(fmt.Sprintf("Unexpected default json index size, %d", size))
+	}
+}
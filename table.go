@@ -2,8 +2,10 @@ package goloquent
 
 import (
 	"context"
+	"fmt"
 
 	"cloud.google.com/go/datastore"
+	"github.com/RevenueMonster/goloquent/expr"
 )
 
 // Table :
@@ -30,7 +32,29 @@ func (t *Table) Upsert(ctx context.Context, model interface{}, parentKey ...*dat
 
 // Migrate :
 func (t *Table) Migrate(ctx context.Context, model interface{}) error {
-	return newBuilder(t.newQuery(), operationWrite).migrate(ctx, model)
+	return t.MigrateWithOptions(ctx, MigrateOptions{}, model)
+}
+
+// MigrateWithOptions :
+func (t *Table) MigrateWithOptions(ctx context.Context, opts MigrateOptions, model interface{}) error {
+	return newBuilder(t.newQuery(), operationWrite).migrateMultiple(ctx, []interface{}{model}, opts)
+}
+
+// PlanMigration :
+func (t *Table) PlanMigration(ctx context.Context, model interface{}) (*TablePlan, error) {
+	return t.PlanMigrationWithOptions(ctx, MigrateOptions{}, model)
+}
+
+// PlanMigrationWithOptions :
+func (t *Table) PlanMigrationWithOptions(ctx context.Context, opts MigrateOptions, model interface{}) (*TablePlan, error) {
+	plan, err := newBuilder(t.newQuery(), operationWrite).planMigration(ctx, []interface{}{model}, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.Tables) == 0 {
+		return nil, fmt.Errorf("goloquent: no migration plan of table %q", t.name)
+	}
+	return plan.Tables[0], nil
 }
 
 // Exists :
@@ -68,6 +92,31 @@ func (t *Table) Select(fields ...string) *Query {
 	return t.newQuery().Select(fields...)
 }
 
+// SelectAggregate :
+func (t *Table) SelectAggregate(aggrs ...expr.Aggregate) *Query {
+	return t.newQuery().SelectAggregate(aggrs...)
+}
+
+// GroupBy :
+func (t *Table) GroupBy(fields ...string) *Query {
+	return t.newQuery().GroupBy(fields...)
+}
+
+// With :
+func (t *Table) With(fields ...string) *Query {
+	return t.newQuery().With(fields...)
+}
+
+// WithParent :
+func (t *Table) WithParent(dst interface{}) *Query {
+	return t.newQuery().WithParent(dst)
+}
+
+// WithChildren :
+func (t *Table) WithChildren(kind string, dst interface{}) *Query {
+	return t.newQuery().WithChildren(kind, dst)
+}
+
 // DistinctOn :
 func (t *Table) DistinctOn(fields ...string) *Query {
 	return t.newQuery().DistinctOn(fields...)
@@ -83,6 +132,16 @@ func (t *Table) Unscoped() *Query {
 	return t.newQuery().Unscoped()
 }
 
+// WithoutScope :
+func (t *Table) WithoutScope(names ...string) *Query {
+	return t.newQuery().WithoutScope(names...)
+}
+
+// OnlyTrashed :
+func (t *Table) OnlyTrashed() *Query {
+	return t.newQuery().OnlyTrashed()
+}
+
 // Find :
 func (t *Table) Find(ctx context.Context, key *datastore.Key, model interface{}) error {
 	return t.newQuery().Find(ctx, key, model)
@@ -98,6 +157,51 @@ func (t *Table) Get(ctx context.Context, model interface{}) error {
 	return t.newQuery().Get(ctx, model)
 }
 
+// Count :
+func (t *Table) Count(ctx context.Context) (int64, error) {
+	return t.newQuery().Count(ctx)
+}
+
+// Sum :
+func (t *Table) Sum(ctx context.Context, field string) (float64, error) {
+	return t.newQuery().Sum(ctx, field)
+}
+
+// Avg :
+func (t *Table) Avg(ctx context.Context, field string) (float64, error) {
+	return t.newQuery().Avg(ctx, field)
+}
+
+// Min :
+func (t *Table) Min(ctx context.Context, field string) (float64, error) {
+	return t.newQuery().Min(ctx, field)
+}
+
+// Max :
+func (t *Table) Max(ctx context.Context, field string) (float64, error) {
+	return t.newQuery().Max(ctx, field)
+}
+
+// Rows :
+func (t *Table) Rows(ctx context.Context, model interface{}) (*Rows, error) {
+	return t.newQuery().Rows(ctx, model)
+}
+
+// Each :
+func (t *Table) Each(ctx context.Context, model interface{}, cb func() error) error {
+	return t.newQuery().Each(ctx, model, cb)
+}
+
+// Chunk :
+func (t *Table) Chunk(ctx context.Context, size int, model interface{}, cb func() error) error {
+	return t.newQuery().Chunk(ctx, size, model, cb)
+}
+
+// ChunkFrom :
+func (t *Table) ChunkFrom(ctx context.Context, cp *Checkpoint, size int, model interface{}, cb func() error) error {
+	return t.newQuery().ChunkFrom(ctx, cp, size, model, cb)
+}
+
 // Paginate :
 func (t *Table) Paginate(ctx context.Context, p *Pagination, model interface{}) error {
 	return t.newQuery().Paginate(ctx, p, model)
@@ -118,6 +222,21 @@ func (t *Table) Where(field, op string, value interface{}) *Query {
 	return t.newQuery().Where(field, op, value)
 }
 
+// WhereAny :
+func (t *Table) WhereAny(cb func(q *Query) *Query) *Query {
+	return t.newQuery().WhereAny(cb)
+}
+
+// Join :
+func (t *Table) Join(kind, localField, op, foreignField string) *Query {
+	return t.newQuery().Join(kind, localField, op, foreignField)
+}
+
+// LeftJoin :
+func (t *Table) LeftJoin(kind, localField, op, foreignField string) *Query {
+	return t.newQuery().LeftJoin(kind, localField, op, foreignField)
+}
+
 // WhereEqual :
 func (t *Table) WhereEqual(field string, v interface{}) *Query {
 	return t.newQuery().WhereEqual(field, v)
@@ -163,6 +282,16 @@ func (t *Table) WhereJSONEqual(field string, v interface{}) *Query {
 	return t.newQuery().WhereJSONEqual(field, v)
 }
 
+// WhereWithinRadius :
+func (t *Table) WhereWithinRadius(field string, p datastore.GeoPoint, meters float64) *Query {
+	return t.newQuery().WhereWithinRadius(field, p, meters)
+}
+
+// WhereWithinBox :
+func (t *Table) WhereWithinBox(field string, sw, ne datastore.GeoPoint) *Query {
+	return t.newQuery().WhereWithinBox(field, sw, ne)
+}
+
 // Lock :
 func (t *Table) Lock(mode locked) *Query {
 	return t.newQuery().Lock(mode)
@@ -183,6 +312,11 @@ func (t *Table) OrderBy(fields ...interface{}) *Query {
 	return t.newQuery().OrderBy(fields...)
 }
 
+// OrderByDistance :
+func (t *Table) OrderByDistance(field string, p datastore.GeoPoint) *Query {
+	return t.newQuery().OrderByDistance(field, p)
+}
+
 // Limit :
 func (t *Table) Limit(limit int) *Query {
 	return t.newQuery().Limit(limit)
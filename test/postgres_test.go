@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"reflect"
 	"testing"
 	"time"
 
+	"cloud.google.com/go/datastore"
 	"github.com/RevenueMonster/goloquent"
 	"github.com/RevenueMonster/goloquent/db"
 	_ "github.com/lib/pq"
@@ -99,6 +101,49 @@ func TestPostgresCreate(t *testing.T) {
 	}
 }
 
+func TestPostgresCopyFrom(t *testing.T) {
+	suffix := time.Now().UnixNano()
+	copied := datastore.NameKey("Merchant", fmt.Sprintf("copy-%d", suffix), nil)
+	inserted := datastore.NameKey("Merchant", fmt.Sprintf("insert-%d", suffix), nil)
+
+	users := []User{*getFakeUser(), *getFakeUser(), *getFakeUser()}
+	if err := pg.Create(ctx, &users, inserted); err != nil {
+		t.Fatal(err)
+	}
+	for i := range users {
+		users[i].Key = nil
+	}
+	if err := pg.SetCopyFrom(true); err != nil {
+		t.Fatal(err)
+	}
+	defer pg.SetCopyFrom(false)
+	if err := pg.Create(ctx, &users, copied); err != nil {
+		t.Fatal(err)
+	}
+
+	// the records of `COPY FROM` must be the same as the records of `INSERT`
+	expected, result := new([]User), new([]User)
+	if err := pg.Ancestor(inserted).OrderBy("Username").Get(ctx, expected); err != nil {
+		t.Fatal(err)
+	}
+	if err := pg.Ancestor(copied).OrderBy("Username").Get(ctx, result); err != nil {
+		t.Fatal(err)
+	}
+	if len(*result) != len(users) || len(*expected) != len(users) {
+		t.Fatal(fmt.Errorf("expected %d users, but end up with %d and %d", len(users), len(*expected), len(*result)))
+	}
+	for i, u := range *result {
+		if u.Key == nil || !u.Key.Parent.Equal(copied) {
+			t.Fatal(fmt.Errorf("unexpected key of copied user, %v", u.Key))
+		}
+		e := (*expected)[i]
+		u.Key, e.Key = nil, nil
+		if !reflect.DeepEqual(u, e) {
+			t.Fatal(fmt.Errorf("unexpected copied user, %+v, expected %+v", u, e))
+		}
+	}
+}
+
 // func TestPostgresReplaceInto(t *testing.T) {
 // 	if err := pg.Table("User").
 // 		AnyOfAncestor(nameKey, idKey).
@@ -491,6 +536,19 @@ func TestPostgresUpsert(t *testing.T) {
 	if err := pg.Upsert(ctx, &uuu, nameKey); err != nil {
 		t.Fatal(err)
 	}
+
+	// the existing record is refreshed on conflict
+	u.Name = "upserted"
+	if err := pg.Upsert(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	uu2 := new(User)
+	if err := pg.Find(ctx, u.Key, uu2); err != nil {
+		t.Fatal(err)
+	}
+	if uu2.Name != "upserted" {
+		t.Fatal(fmt.Errorf("expected name is updated on conflict, got %q", uu2.Name))
+	}
 }
 
 func TestPostgresUpdate(t *testing.T) {
@@ -0,0 +1,2361 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/RevenueMonster/goloquent"
+	"github.com/RevenueMonster/goloquent/db"
+	"github.com/RevenueMonster/goloquent/expr"
+	"github.com/mattn/go-sqlite3"
+)
+
+var (
+	lite *goloquent.DB
+)
+
+func TestSQLiteConn(t *testing.T) {
+	conn, err := db.Open(ctx, "sqlite", db.Config{
+		Database: "file::memory:",
+		Logger: func(ctx context.Context, stmt *goloquent.Stmt) {
+			log.Println(fmt.Sprintf("[%.3fms] %s", stmt.TimeElapse().Seconds()*1000, stmt.String()))
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+	lite = conn
+}
+
+func TestSQLiteDropTableIfExists(t *testing.T) {
+	if err := lite.Table("User").DropIfExists(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLiteMigration(t *testing.T) {
+	if err := lite.Migrate(ctx, new(User), new(TempUser)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLitePlanMigration(t *testing.T) {
+	plan, err := lite.PlanMigration(ctx, new(User))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.HasChanges() || len(plan.Statements()) > 0 {
+		t.Fatal(fmt.Errorf("unexpected migration plan, %v", plan.Statements()))
+	}
+
+	type Product struct {
+		Key    *datastore.Key `goloquent:"__key__"`
+		Name   string
+		Price  int
+		Remark string
+	}
+	type ProductV2 struct {
+		Key         *datastore.Key `goloquent:"__key__"`
+		Name        string         `goloquent:",index"`
+		Price       float64
+		Description string
+	}
+
+	tb := lite.Table("Product")
+	if err := tb.DropIfExists(ctx); err != nil {
+		t.Fatal(err)
+	}
+	p, err := tb.PlanMigration(ctx, new(Product))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.IsNew || len(p.AddedColumns) != 4 || len(p.Statements) != 1 {
+		t.Fatal(fmt.Errorf("unexpected migration plan, %v", p))
+	}
+	if tb.Exists(ctx) {
+		t.Fatal(`Unexpected result, plan shouldn't create table "Product"`)
+	}
+	if err := tb.Migrate(ctx, new(Product)); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err = tb.PlanMigration(ctx, new(ProductV2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.IsNew ||
+		len(p.AddedColumns) != 1 || p.AddedColumns[0].Name != "Description" ||
+		len(p.ChangedColumns) != 1 || p.ChangedColumns[0].Name != "Price" ||
+		len(p.DroppedColumns) != 1 || p.DroppedColumns[0] != "Remark" ||
+		len(p.AddedIndexes) != 1 || p.AddedIndexes[0].Name != "Product_Name_idx" {
+		t.Fatal(fmt.Errorf("unexpected migration plan, %v", p))
+	}
+	if len(p.Statements) != 2 {
+		t.Fatal(fmt.Errorf("unexpected migration statements, %v", p.Statements))
+	}
+
+	// nothing should be applied
+	p, err = tb.PlanMigration(ctx, new(ProductV2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.AddedColumns) != 1 || len(p.AddedIndexes) != 1 {
+		t.Fatal(fmt.Errorf("unexpected migration plan, %v", p))
+	}
+}
+
+func TestSQLiteMigrateOptions(t *testing.T) {
+	type Product struct {
+		Key         *datastore.Key `goloquent:"__key__"`
+		Name        string         `goloquent:",index"`
+		Price       float64
+		Description string
+	}
+	type ProductV3 struct {
+		Key   *datastore.Key `goloquent:"__key__"`
+		Name  string
+		Price float64
+	}
+
+	tb := lite.Table("Product")
+	if err := tb.Migrate(ctx, new(Product)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.Migrate(ctx, new(ProductV3)); err != nil {
+		t.Fatal(err)
+	}
+	p, err := tb.PlanMigration(ctx, new(ProductV3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.DroppedColumns) != 2 || len(p.DroppedIndexes) != 1 {
+		t.Fatal(fmt.Errorf("orphan column and index should remain without options, %v", p))
+	}
+
+	opts := goloquent.MigrateOptions{DropColumns: true, DropIndexes: true}
+	if err := lite.Migrate(ctx, opts, new(Product)); err == nil {
+		t.Fatal(fmt.Errorf("misplaced migrate options should be rejected"))
+	}
+	if _, err := lite.PlanMigration(ctx, opts); err == nil {
+		t.Fatal(fmt.Errorf("misplaced migrate options should be rejected"))
+	}
+	if err := lite.MigrateWithOptions(ctx, opts, new(Product)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.MigrateWithOptions(ctx, opts, new(ProductV3)); err != nil {
+		t.Fatal(err)
+	}
+	p, err = tb.PlanMigrationWithOptions(ctx, opts, new(ProductV3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// sqlite unable to change the data type of column
+	if len(p.DroppedColumns) != 0 || len(p.DroppedIndexes) != 0 || len(p.Statements) != 0 {
+		t.Fatal(fmt.Errorf("unexpected migration plan, %v", p))
+	}
+}
+
+func TestSQLiteJSONIndex(t *testing.T) {
+	type Address struct {
+		CountryCode string
+		City        string
+	}
+	type Shop struct {
+		Key     *datastore.Key `goloquent:"__key__"`
+		Address Address        `goloquent:",jsonindex=$.CountryCode|$.City"`
+	}
+	type ShopV2 struct {
+		Key     *datastore.Key `goloquent:"__key__"`
+		Address Address        `goloquent:",jsonindex=$.CountryCode"`
+	}
+
+	tb := lite.Table("Shop")
+	if err := tb.DropIfExists(ctx); err != nil {
+		t.Fatal(err)
+	}
+	p, err := tb.PlanMigration(ctx, new(Shop))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.AddedIndexes) != 2 || p.AddedIndexes[1].Name != "Shop_Address$City_idx" {
+		t.Fatal(fmt.Errorf("unexpected json index, %v", p.AddedIndexes))
+	}
+	if err := tb.Migrate(ctx, new(Shop)); err != nil {
+		t.Fatal(err)
+	}
+	p, err = tb.PlanMigration(ctx, new(Shop))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.HasChanges() {
+		t.Fatal(fmt.Errorf("json index migration should be idempotent, %v", p))
+	}
+
+	if err := tb.Create(ctx, &Shop{Address: Address{CountryCode: "MY", City: "Kuala Lumpur"}}); err != nil {
+		t.Fatal(err)
+	}
+	shops := new([]Shop)
+	if err := tb.WhereJSONEqual("Address>CountryCode", "MY").Get(ctx, shops); err != nil {
+		t.Fatal(err)
+	}
+	if len(*shops) != 1 {
+		t.Fatal(fmt.Errorf("unexpected result, expected 1 record, but end up with %d", len(*shops)))
+	}
+
+	opts := goloquent.MigrateOptions{DropIndexes: true}
+	p, err = tb.PlanMigrationWithOptions(ctx, opts, new(ShopV2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.DroppedIndexes) != 1 || p.DroppedIndexes[0] != "Shop_Address$City_idx" {
+		t.Fatal(fmt.Errorf("unexpected dropped index, %v", p.DroppedIndexes))
+	}
+	if err := tb.MigrateWithOptions(ctx, opts, new(ShopV2)); err != nil {
+		t.Fatal(err)
+	}
+
+	type InvalidShop struct {
+		Key     *datastore.Key `goloquent:"__key__"`
+		Address Address        `goloquent:",jsonindex=$.City'"`
+	}
+	if err := tb.Migrate(ctx, new(InvalidShop)); err == nil {
+		t.Fatal("Expected error on invalid json path")
+	}
+}
+
+func TestSQLiteVersionedMigration(t *testing.T) {
+	type Merchant struct {
+		Key  *datastore.Key `goloquent:"__key__"`
+		Name string
+	}
+
+	goloquent.RegisterMigration("20210101000000_create_merchant",
+		func(ctx context.Context, db *goloquent.DB) error {
+			return db.Migrate(ctx, new(Merchant))
+		},
+		func(ctx context.Context, db *goloquent.DB) error {
+			return db.Table("Merchant").DropIfExists(ctx)
+		})
+	goloquent.RegisterMigration("20210102000000_seed_merchant",
+		func(ctx context.Context, db *goloquent.DB) error {
+			return db.Create(ctx, &Merchant{Name: "Revenue Monster"})
+		},
+		func(ctx context.Context, db *goloquent.DB) error {
+			return db.Table("Merchant").Truncate(ctx)
+		})
+
+	if err := lite.MigrateUp(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !lite.Table("Merchant").Exists(ctx) {
+		t.Fatal(`Unexpected result, table "Merchant" should exists`)
+	}
+
+	states, err := lite.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 2 || !states[0].IsApplied || !states[1].IsApplied || states[1].Batch != 1 {
+		t.Fatal(fmt.Errorf("unexpected migration status, %v", states))
+	}
+
+	// rerun should be no-op
+	if err := lite.MigrateUp(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lite.MigrateDown(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if lite.Table("Merchant").Exists(ctx) {
+		t.Fatal(`Unexpected result, table "Merchant" should be dropped`)
+	}
+	states, err = lite.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, st := range states {
+		if st.IsApplied {
+			t.Fatal(fmt.Errorf("unexpected migration status, %v", st))
+		}
+	}
+}
+
+func TestSQLiteTableExists(t *testing.T) {
+	if isExist := lite.Table("User").Exists(ctx); isExist != true {
+		t.Fatal(fmt.Errorf("Unexpected error, table %q should exists", "User"))
+	}
+}
+
+func TestSQLiteTruncate(t *testing.T) {
+	if err := lite.Truncate(ctx, new(User), TempUser{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLiteAddIndex(t *testing.T) {
+	if err := lite.Table("User").
+		AddUniqueIndex(ctx, "Username"); err != nil {
+		t.Fatal(err)
+	}
+	if err := lite.Table("User").
+		AddIndex(ctx, "Age"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLiteEmptyInsertOrUpsert(t *testing.T) {
+	var users []User
+	if err := lite.Create(ctx, &users); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lite.Upsert(ctx, &users); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLiteCreate(t *testing.T) {
+	u := getFakeUser()
+	if err := lite.Create(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+
+	u = getFakeUser()
+	if err := lite.Create(ctx, u, nameKey); err != nil {
+		t.Fatal(err)
+	}
+
+	u = getFakeUser()
+	if err := lite.Create(ctx, u, idKey); err != nil {
+		t.Fatal(err)
+	}
+
+	uu := []User{*getFakeUser(), *getFakeUser()}
+	if err := lite.Create(ctx, &uu); err != nil {
+		t.Fatal(err)
+	}
+
+	users := []*User{getFakeUser(), getFakeUser()}
+	if err := lite.Create(ctx, &users); err != nil {
+		t.Fatal(err)
+	}
+
+	var i *User
+	if err := lite.Create(ctx, i); err == nil {
+		t.Fatal(err)
+	}
+
+	users = []*User{nil, nil}
+	if err := lite.Create(ctx, &users); err == nil {
+		t.Fatal(err)
+	}
+
+	users = []*User{getFakeUser(), getFakeUser()}
+	if err := lite.Create(ctx, &users, symbolKey); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLiteReplaceInto(t *testing.T) {
+	if err := lite.Table("User").
+		AnyOfAncestor(nameKey, idKey).
+		ReplaceInto(ctx, "TempUser"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLiteInsertInto(t *testing.T) {
+	if err := lite.Table("ArchiveUser").
+		Migrate(ctx, new(User)); err != nil {
+		t.Fatal(err)
+	}
+	if err := lite.Table("User").
+		AnyOfAncestor(nameKey, idKey).
+		InsertInto(ctx, "ArchiveUser"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLiteSave(t *testing.T) {
+	var u User
+	if err := lite.Save(ctx, u); err == nil {
+		t.Fatal(errors.New("`Save` func must addressable"))
+	}
+	if err := lite.Save(ctx, nil); err == nil {
+		t.Fatal(errors.New("nil entity suppose not allow in `Save` func"))
+	}
+
+	if err := lite.Create(ctx, &u); err != nil {
+		t.Fatal(err)
+	}
+	u.Name = "Something"
+	if err := lite.Save(ctx, &u); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLiteSelect(t *testing.T) {
+	u := new(User)
+	if err := lite.Select("*", "Name").First(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLiteSubQuery(t *testing.T) {
+	users := new([]User)
+	if err := lite.Where("PrimaryEmail", "in",
+		db.Table("User").
+			Select("PrimaryEmail").
+			WhereNotNull("PrimaryEmail").
+			WhereIn("PrimaryEmail", []string{
+				"DgHlUKz@pYEXo.ru",
+				"sianloong@hotmail.com",
+			})).Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLiteDistinctOn(t *testing.T) {
+	u := new(User)
+	if err := lite.NewQuery().
+		DistinctOn("*").First(ctx, u); err == nil {
+		t.Fatal("Expected `DistinctOn` cannot allow *")
+	}
+
+	if err := lite.NewQuery().
+		DistinctOn("").First(ctx, u); err == nil {
+		t.Fatal("Expected `DistinctOn` cannot have empty")
+	}
+
+	if err := lite.NewQuery().
+		DistinctOn("Name", "Password").First(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLiteGet(t *testing.T) {
+	type NewUser struct {
+		User
+		Arr    []string
+		Struct struct {
+			Name string
+		}
+		Data json.RawMessage
+		Geo  datastore.GeoPoint
+	}
+
+	nu := new(NewUser)
+	if err := lite.Table("User").Migrate(ctx, nu); err != nil {
+		t.Fatal(err)
+	}
+
+	// json null shouldn't run panic when retrieve out
+	o := new(NewUser)
+	if err := db.Table("User").First(ctx, o); err != nil {
+		t.Fatal(err)
+	}
+
+	if o.Key == nil || len(o.Arr) != 0 {
+		t.Fatal(errors.New("unexpected result"))
+	}
+
+	u := new(User)
+	// restore back to original structure
+	if err := lite.Migrate(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	if err := lite.First(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lite.Find(ctx, u.Key, u); err != nil {
+		t.Fatal(err)
+	}
+
+	users := new([]User)
+	if err := lite.Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lite.NewQuery().Unscoped().Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+
+	u2 := getFakeUser()
+	u2.Key = symbolKey
+	if err := lite.Create(ctx, u2); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lite.Find(ctx, u2.Key, u2); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lite.Where("$Key", "=", u2.Key).First(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	if u.Key == nil {
+		t.Fatal("unexpected result")
+	}
+}
+
+func TestSQLiteAncestor(t *testing.T) {
+	users := new([]User)
+	if err := lite.Ancestor(idKey).
+		Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+	if len(*users) <= 0 {
+		t.Fatal(`Unexpected result from filter "Ancestor" using id key`)
+	}
+
+	if err := lite.Ancestor(nameKey).
+		Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+	if len(*users) <= 0 {
+		t.Fatal(`Unexpected result from filter "Ancestor" using name key`)
+	}
+
+	if err := lite.AnyOfAncestor(idKey, nameKey).Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lite.Ancestor(symbolKey).Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+	if len(*users) <= 0 {
+		t.Fatal(`Unexpected result from filter "Ancestor" using name key with symbol`)
+	}
+}
+
+func TestSQLiteWhereFilter(t *testing.T) {
+	age := uint8(85)
+	creditLimit := float64(100.015)
+	dob, _ := time.Parse("2006-01-02", "1900-10-01")
+
+	u := getFakeUser()
+	u.Age = age
+	u.Nickname = nil
+	u.CreditLimit = creditLimit
+	u.Birthdate = goloquent.Date(dob)
+
+	lite.Create(ctx, u)
+
+	users := new([]User)
+	if err := lite.Where("Age", "=", &age).
+		Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+	if len(*users) <= 0 {
+		t.Fatal(`Unexpected result from filter using "Where"`)
+	}
+
+	if err := lite.Where("Birthdate", "=", goloquent.Date(dob)).
+		Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+	if len(*users) <= 0 {
+		t.Fatal(`Unexpected result from filter using "Where"`)
+	}
+
+	var nilNickname *string
+	if err := lite.Where("Nickname", "=", nilNickname).
+		Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+	if len(*users) <= 0 {
+		t.Fatal(`Unexpected result from filter using "Where"`)
+	}
+
+	if err := lite.Where("CreditLimit", "=", &creditLimit).
+		Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+	if len(*users) <= 0 {
+		t.Fatal(`Unexpected result from filter using "Where"`)
+	}
+}
+
+func TestSQLiteWhereAnyLike(t *testing.T) {
+	users := new([]User)
+
+	u := getFakeUser()
+	u.PrimaryEmail = "sianloong@hotmail.com"
+	if err := lite.Create(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lite.NewQuery().
+		WhereAnyLike("PrimaryEmail", []string{
+			"lzPskFb@OOxzA.net",
+			"sianloong%",
+		}).Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*users) <= 0 {
+		t.Fatal(`Unexpected result from filter using "WhereAnyLike"`)
+	}
+}
+
+func TestSQLiteJSONRawMessage(t *testing.T) {
+	u := getFakeUser()
+	if err := lite.Upsert(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	u.Information = nil
+	if err := lite.Upsert(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	u.Information = json.RawMessage(`[]`)
+	if err := lite.Upsert(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	u.Information = json.RawMessage(`{}`)
+	if err := lite.Upsert(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	u.Information = json.RawMessage(`null`)
+	if err := lite.Upsert(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	u.Information = json.RawMessage(`notvalid`)
+	if err := lite.Upsert(ctx, u); err == nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLiteEmptySliceInJSON(t *testing.T) {
+	u := new(User)
+	if err := lite.First(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	if u.Emails == nil {
+		t.Fatal(fmt.Errorf("empty slice should init on any `Get` func"))
+	}
+
+	u2 := getFakeUser()
+	u2.Emails = nil
+	u2.PrimaryEmail = "sianloong@hotmail.com"
+	if err := lite.Create(ctx, u2); err != nil {
+		t.Fatal(err)
+	}
+	if u2.Emails == nil {
+		t.Fatal(fmt.Errorf("empty slice should init on any `Create` func"))
+	}
+}
+
+func TestSQLiteJSONEqual(t *testing.T) {
+	users := new([]User)
+	if err := lite.NewQuery().
+		WhereJSONEqual("Address>PostCode", int32(85)).
+		Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lite.NewQuery().
+		WhereJSONEqual("Address>PostCode", uint32(85)).
+		Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+
+	postCode := uint32(85)
+	if err := lite.NewQuery().
+		WhereJSONEqual("Address>PostCode", &postCode).
+		Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lite.NewQuery().
+		WhereJSONEqual("Address>Line1", "7812, Jalan Section 22").
+		Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+	if len(*users) <= 0 {
+		t.Fatal("JSON equal has unexpected result")
+	}
+
+	var emptyStr string
+	if err := lite.NewQuery().
+		WhereJSONEqual("Address>Line2", emptyStr).
+		Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+	if len(*users) <= 0 {
+		t.Fatal("JSON equal has unexpected result")
+	}
+
+	timeZone := new(time.Time)
+	if err := lite.NewQuery().
+		WhereJSONEqual("Address>region.TimeZone", timeZone).
+		Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+	if len(*users) <= 0 {
+		t.Fatal("JSON equal has unexpected result")
+	}
+}
+
+func TestSQLiteJSONNotEqual(t *testing.T) {
+	var timeZone *time.Time
+	users := new([]User)
+	if err := lite.NewQuery().
+		WhereJSONNotEqual("Address>region.TimeZone", timeZone).
+		Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+	if len(*users) <= 0 {
+		t.Fatal("JSON equal has unexpected result")
+	}
+
+	if err := lite.NewQuery().
+		WhereJSONNotEqual("Address>Country", "").
+		Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+	if len(*users) > 0 {
+		t.Fatal("JSON equal has unexpected result")
+	}
+}
+
+func TestSQLiteJSONIn(t *testing.T) {
+	users := new([]User)
+	if err := lite.NewQuery().
+		WhereJSONIn("Address>PostCode", []interface{}{0, 10, 20}).
+		Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+	if len(*users) <= 0 {
+		t.Fatal("JSON in has unexpected result")
+	}
+}
+
+func TestSQLiteJSONNotIn(t *testing.T) {
+	users := new([]User)
+	if err := lite.NewQuery().
+		WhereJSONNotIn("Address>Line1", []interface{}{"PJ", "KL", "Cheras"}).
+		Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+	if len(*users) <= 0 {
+		t.Fatal("JSON contain any has unexpected result")
+	}
+}
+
+func TestSQLiteJSONContainAny(t *testing.T) {
+	users := new([]User)
+	if err := lite.NewQuery().
+		WhereJSONContainAny("Emails", []Email{
+			"support@hotmail.com",
+			"invalid@gmail.com",
+		}).Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+	if len(*users) <= 0 {
+		t.Fatal("JSON contain any has unexpected result")
+	}
+
+	if err := lite.NewQuery().
+		WhereJSONContainAny("Emails", []Email{
+			"invalid@gmail.com",
+			"invalid@hotmail.com",
+		}).Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+	if len(*users) > 0 {
+		t.Fatal("JSON contain any has unexpected result")
+	}
+}
+
+func TestSQLiteJSONType(t *testing.T) {
+	users := new([]User)
+	if err := lite.NewQuery().
+		WhereJSONType("Address>region", "OBJECT").
+		Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+	if len(*users) <= 0 {
+		t.Fatal("JSON isObject has unexpected result")
+	}
+}
+
+func TestSQLiteJSONIsObject(t *testing.T) {
+	users := new([]User)
+	if err := lite.NewQuery().
+		WhereJSONIsObject("Address>region").
+		Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+	if len(*users) <= 0 {
+		t.Fatal("JSON isObject has unexpected result")
+	}
+}
+
+func TestSQLiteJSONIsArray(t *testing.T) {
+	users := new([]User)
+	if err := lite.NewQuery().
+		WhereJSONIsArray("Address>region.keys").
+		Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+	if len(*users) <= 0 {
+		t.Fatal("JSON isArray has unexpected result")
+	}
+}
+
+func TestSQLitePaginate(t *testing.T) {
+	users := new([]User)
+	p := &goloquent.Pagination{
+		Limit: 1,
+	}
+	if err := lite.Paginate(ctx, p, users); err != nil {
+		t.Fatal(err)
+	}
+	if len(*(users)) <= 0 {
+		t.Fatal(fmt.Errorf("paginate record set shouldn't empty"))
+	}
+
+	p.Cursor = p.NextCursor()
+	if err := lite.Paginate(ctx, p, users); err != nil {
+		t.Fatal(err)
+	}
+	if len(*(users)) <= 0 {
+		t.Fatal(fmt.Errorf("paginate record set shouldn't empty"))
+	}
+
+	p2 := &goloquent.Pagination{
+		Limit: 1,
+	}
+	if err := lite.Ancestor(nameKey).
+		Paginate(ctx, p2, users); err != nil {
+		t.Fatal(err)
+	}
+	if len(*(users)) <= 0 {
+		t.Fatal(fmt.Errorf("paginate record set shouldn't empty"))
+	}
+
+	p2.Cursor = p.NextCursor()
+	if err := lite.Paginate(ctx, p2, users); err != nil {
+		t.Fatal(err)
+	}
+	if len(*(users)) <= 0 {
+		t.Fatal(fmt.Errorf("paginate record set shouldn't empty"))
+	}
+}
+
+func TestSQLiteEagerLoading(t *testing.T) {
+	type Category struct {
+		Key  *datastore.Key `goloquent:"__key__"`
+		Name string
+	}
+	type Product struct {
+		Key            *datastore.Key `goloquent:"__key__"`
+		Name           string
+		Category       *datastore.Key
+		CategoryEntity *Category `goloquent:",with=Category"`
+		Related        []*datastore.Key
+		RelatedEntity  []Category `goloquent:",with=Related"`
+	}
+
+	if err := lite.Table("Product").DropIfExists(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := lite.Migrate(ctx, new(Category), new(Product)); err != nil {
+		t.Fatal(err)
+	}
+	categories := []*Category{{Name: "Food"}, {Name: "Drink"}, {Name: "Snack"}}
+	if err := lite.Create(ctx, &categories); err != nil {
+		t.Fatal(err)
+	}
+	products := []*Product{
+		{Name: "Burger", Category: categories[0].Key, Related: []*datastore.Key{categories[1].Key, categories[2].Key}},
+		{Name: "Coke", Category: categories[1].Key},
+		{Name: "Water"},
+	}
+	if err := lite.Create(ctx, &products); err != nil {
+		t.Fatal(err)
+	}
+
+	result := make([]Product, 0)
+	if err := lite.With("Category", "Related").
+		OrderBy("Name").
+		Get(ctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 3 {
+		t.Fatal(fmt.Errorf("unexpected result, %v", result))
+	}
+	if result[0].CategoryEntity == nil || result[0].CategoryEntity.Name != "Food" {
+		t.Fatal(fmt.Errorf("unexpected eager loading result, %v", result[0].CategoryEntity))
+	}
+	if len(result[0].RelatedEntity) != 2 || result[0].RelatedEntity[1].Name != "Snack" {
+		t.Fatal(fmt.Errorf("unexpected eager loading result, %v", result[0].RelatedEntity))
+	}
+	if result[1].CategoryEntity == nil || result[1].CategoryEntity.Name != "Drink" {
+		t.Fatal(fmt.Errorf("unexpected eager loading result, %v", result[1].CategoryEntity))
+	}
+	if result[2].CategoryEntity != nil {
+		t.Fatal(fmt.Errorf("unexpected eager loading result, %v", result[2].CategoryEntity))
+	}
+
+	p := new(Product)
+	if err := lite.With("Category").Find(ctx, products[1].Key, p); err != nil {
+		t.Fatal(err)
+	}
+	if p.CategoryEntity == nil || p.CategoryEntity.Name != "Drink" {
+		t.Fatal(fmt.Errorf("unexpected eager loading result, %v", p.CategoryEntity))
+	}
+
+	if err := lite.With("Name").Get(ctx, &result); err == nil {
+		t.Fatal("expected error on relation which is not a key field")
+	}
+}
+
+func TestSQLiteAncestorLoading(t *testing.T) {
+	type Merchant struct {
+		Key  *datastore.Key `goloquent:"__key__"`
+		Name string
+	}
+	type Store struct {
+		Key  *datastore.Key `goloquent:"__key__"`
+		Name string
+	}
+	type Terminal struct {
+		Key    *datastore.Key `goloquent:"__key__"`
+		Serial string
+	}
+
+	if err := lite.Migrate(ctx, new(Merchant), new(Store), new(Terminal)); err != nil {
+		t.Fatal(err)
+	}
+	merchants := []*Merchant{{Name: "A"}, {Name: "B"}}
+	if err := lite.Create(ctx, &merchants); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range merchants {
+		stores := []*Store{{Name: m.Name + "1"}, {Name: m.Name + "2"}}
+		if err := lite.Create(ctx, &stores, m.Key); err != nil {
+			t.Fatal(err)
+		}
+		if err := lite.Create(ctx, &Terminal{Serial: m.Name + "-T"}, stores[0].Key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stores := make([]Store, 0)
+	parents := make([]*Merchant, 0)
+	terminals := make([]Terminal, 0)
+	if err := lite.WithParent(&parents).
+		WithChildren("Terminal", &terminals).
+		WhereLike("Name", "A%").
+		Get(ctx, &stores); err != nil {
+		t.Fatal(err)
+	}
+	if len(stores) != 2 || len(parents) != 1 || parents[0].Name != "A" {
+		t.Fatal(fmt.Errorf("unexpected parent result, %v, %v", stores, parents))
+	}
+	if len(terminals) != 1 || terminals[0].Serial != "A-T" {
+		t.Fatal(fmt.Errorf("unexpected children result, %v", terminals))
+	}
+
+	stores = make([]Store, 0)
+	if err := lite.Table("Merchant").
+		WithChildren("Store", &stores).
+		Get(ctx, &merchants); err != nil {
+		t.Fatal(err)
+	}
+	if len(stores) != 4 {
+		t.Fatal(fmt.Errorf("unexpected children result, %v", stores))
+	}
+
+	if err := lite.WithParent(parents).Get(ctx, &stores); err == nil {
+		t.Fatal("expected error on non pointer destination")
+	}
+}
+
+func TestSQLiteGeoFilter(t *testing.T) {
+	type Outlet struct {
+		Key      *datastore.Key `goloquent:"__key__"`
+		Name     string
+		Location datastore.GeoPoint
+	}
+
+	if err := lite.Migrate(ctx, new(Outlet)); err != nil {
+		t.Fatal(err)
+	}
+	outlets := []*Outlet{
+		{Name: "Kuala Lumpur", Location: datastore.GeoPoint{Lat: 3.1390, Lng: 101.6869}},
+		{Name: "Petaling Jaya", Location: datastore.GeoPoint{Lat: 3.1073, Lng: 101.6067}},
+		{Name: "Singapore", Location: datastore.GeoPoint{Lat: 1.3521, Lng: 103.8198}},
+	}
+	if err := lite.Create(ctx, &outlets); err != nil {
+		t.Fatal(err)
+	}
+
+	kl := outlets[0].Location
+	result := make([]Outlet, 0)
+	if err := lite.NewQuery().
+		WhereWithinRadius("Location", kl, 20000).
+		OrderByDistance("Location", kl).
+		Get(ctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 || result[0].Name != "Kuala Lumpur" || result[1].Name != "Petaling Jaya" {
+		t.Fatal(fmt.Errorf("unexpected radius result, %v", result))
+	}
+
+	if err := lite.NewQuery().
+		WhereWithinBox("Location",
+			datastore.GeoPoint{Lat: 1, Lng: 103},
+			datastore.GeoPoint{Lat: 2, Lng: 104}).
+		Get(ctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 1 || result[0].Name != "Singapore" {
+		t.Fatal(fmt.Errorf("unexpected box result, %v", result))
+	}
+
+	if err := lite.NewQuery().
+		OrderByDistance("-Location", kl).
+		Get(ctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 3 || result[0].Name != "Singapore" || result[2].Name != "Kuala Lumpur" {
+		t.Fatal(fmt.Errorf("unexpected distance sorting result, %v", result))
+	}
+
+	if err := lite.NewQuery().
+		WhereWithinRadius("Location", datastore.GeoPoint{Lat: 100}, 10).
+		Get(ctx, &result); err == nil {
+		t.Fatal("expected error on invalid geo point")
+	}
+}
+
+func TestSQLiteUpsert(t *testing.T) {
+	u := getFakeUser()
+	if err := lite.Upsert(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+
+	u = getFakeUser()
+	if err := lite.Upsert(ctx, u, idKey); err != nil {
+		t.Fatal(err)
+	}
+
+	u = getFakeUser()
+	if err := lite.Upsert(ctx, u, nameKey); err != nil {
+		t.Fatal(err)
+	}
+
+	users := []*User{getFakeUser(), getFakeUser()}
+	if err := lite.Upsert(ctx, &users); err != nil {
+		t.Fatal(err)
+	}
+
+	uu := []User{*getFakeUser(), *getFakeUser()}
+	if err := lite.Upsert(ctx, &uu); err != nil {
+		t.Fatal(err)
+	}
+
+	uuu := []User{*getFakeUser(), *getFakeUser()}
+	if err := lite.Upsert(ctx, &uuu, idKey); err != nil {
+		t.Fatal(err)
+	}
+
+	uuu = []User{*getFakeUser(), *getFakeUser()}
+	if err := lite.Upsert(ctx, &uuu, nameKey); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLiteUpdate(t *testing.T) {
+	if err := lite.Table("User").Limit(1).
+		Where("Name", "=", "Dr. Antoinette Zboncak").
+		Update(ctx, map[string]interface{}{
+			"Name": "sianloong",
+		}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lite.Table("User").Limit(1).
+		Update(ctx, map[string]interface{}{
+			"Emails": []string{"abc@gmail.com", "abc@hotmail.com", "abc@yahoo.com"},
+		}); err != nil {
+		t.Fatal(err)
+	}
+
+	// TODO: support struct
+	// if err := lite.Table("User").Limit(1).
+	// 	Update(map[string]interface{}{
+	// 		"Address": Address{"", "Line2", "", 63000},
+	// 	}); err != nil {
+	// 	t.Fatal(err)
+	// }
+}
+func TestSQLiteSoftDelete(t *testing.T) {
+	u := getFakeUser()
+	if err := lite.Create(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	if err := lite.Delete(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLiteRestore(t *testing.T) {
+	u := getFakeUser()
+	if err := lite.Create(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	if err := lite.Delete(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	if err := lite.Find(ctx, u.Key, new(User)); err != goloquent.ErrNoSuchEntity {
+		t.Fatal("soft deleted entity should not be found", err)
+	}
+
+	users := []User{}
+	if err := lite.NewQuery().OnlyTrashed().WhereEqual("$Key", u.Key).Get(ctx, &users); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 {
+		t.Fatalf("expected 1 trashed user, got %d", len(users))
+	}
+
+	if err := lite.Restore(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	if err := lite.Find(ctx, u.Key, new(User)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lite.Delete(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	if err := lite.Table("User").OnlyTrashed().WhereEqual("$Key", u.Key).Restore(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := lite.Find(ctx, u.Key, new(User)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lite.Delete(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	if err := lite.Table("User").OnlyTrashed().WhereEqual("$Key", u.Key).ForceDelete(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := lite.NewQuery().Unscoped().WhereEqual("$Key", u.Key).Get(ctx, &users); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 0 {
+		t.Fatalf("expected force deleted user to be removed, got %d", len(users))
+	}
+}
+
+func TestSQLiteHardDelete(t *testing.T) {
+	u := new(User)
+	if err := lite.First(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	if err := lite.Destroy(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLiteTable(t *testing.T) {
+	uuu := []*User{getFakeUser(), getFakeUser()}
+	if err := lite.Table("TempUser").Create(ctx, &uuu); err != nil {
+		t.Fatal(err)
+	}
+
+	users := new([]User)
+	if err := lite.Table("User").
+		WhereLike("Name", "nick%").
+		Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lite.Table("User").
+		Where("Age", ">", 0).
+		Get(ctx, users); err != nil {
+		t.Fatal(err)
+	}
+
+	user := new(User)
+	if err := lite.Table("User").
+		First(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLiteRunInTransaction(t *testing.T) {
+	if err := lite.RunInTransaction(func(txn *goloquent.DB) error {
+		u := new(User)
+		if err := txn.NewQuery().
+			WLock().First(ctx, u); err != nil {
+			return err
+		}
+
+		u.Name = "NewName"
+		u.UpdatedDateTime = time.Now().UTC()
+		return txn.Save(ctx, u)
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLiteRunInTransactionContext(t *testing.T) {
+	type Voucher struct {
+		Key  *datastore.Key `goloquent:"__key__"`
+		Code string
+	}
+
+	tb := lite.Table("Voucher")
+	if err := tb.DropIfExists(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.Migrate(ctx, new(Voucher)); err != nil {
+		t.Fatal(err)
+	}
+
+	errRollback := fmt.Errorf("rollback savepoint")
+	if err := lite.RunInTransactionContext(ctx, &sql.TxOptions{}, func(txn *goloquent.DB) error {
+		if err := txn.Table("Voucher").Create(ctx, &Voucher{Code: "A"}); err != nil {
+			return err
+		}
+		if err := txn.RunInTransaction(func(sp *goloquent.DB) error {
+			if err := sp.Table("Voucher").Create(ctx, &Voucher{Code: "B"}); err != nil {
+				return err
+			}
+			return errRollback
+		}); err != errRollback {
+			return fmt.Errorf("unexpected error from savepoint, %v", err)
+		}
+		return txn.RunInTransactionContext(ctx, nil, func(sp *goloquent.DB) error {
+			return sp.Table("Voucher").Create(ctx, &Voucher{Code: "C"})
+		})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	vouchers := new([]Voucher)
+	if err := tb.OrderBy("Code").Get(ctx, vouchers); err != nil {
+		t.Fatal(err)
+	}
+	if len(*vouchers) != 2 || (*vouchers)[0].Code != "A" || (*vouchers)[1].Code != "C" {
+		t.Fatal(fmt.Errorf("unexpected result after savepoint, %v", *vouchers))
+	}
+}
+
+func TestSQLiteRetryTransaction(t *testing.T) {
+	type Wallet struct {
+		Key     *datastore.Key `goloquent:"__key__"`
+		Balance int64
+	}
+
+	tb := lite.Table("Wallet")
+	if err := tb.DropIfExists(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.Migrate(ctx, new(Wallet)); err != nil {
+		t.Fatal(err)
+	}
+
+	errConflict := errors.New("conflict")
+	lite.SetRetryPolicy(goloquent.RetryPolicy{
+		MaxAttempts: 3,
+		Backoff: func(attempt int) time.Duration {
+			return time.Millisecond
+		},
+		IsRetryable: func(err error) bool {
+			return errors.Is(err, errConflict)
+		},
+	})
+	defer lite.SetRetryPolicy(goloquent.RetryPolicy{})
+
+	attempts := 0
+	if err := lite.RunInTransaction(func(txn *goloquent.DB) error {
+		attempts++
+		if err := txn.Table("Wallet").Create(ctx, &Wallet{Balance: 100}); err != nil {
+			return err
+		}
+		if attempts < 3 {
+			return fmt.Errorf("attempt %d, %w", attempts, errConflict)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	wallets := new([]Wallet)
+	if err := tb.Get(ctx, wallets); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 3 || len(*wallets) != 1 {
+		t.Fatal(fmt.Errorf("unexpected retry, %d attempts with %d records", attempts, len(*wallets)))
+	}
+
+	attempts = 0
+	if err := lite.RunInTransaction(func(txn *goloquent.DB) error {
+		attempts++
+		return errConflict
+	}); !errors.Is(err, errConflict) || attempts != 3 {
+		t.Fatal(fmt.Errorf("expected error after max attempts, but end up with %v after %d attempts", err, attempts))
+	}
+}
+
+func TestSQLiteErrorClassification(t *testing.T) {
+	type Coupon struct {
+		Key  *datastore.Key `goloquent:"__key__"`
+		Code string
+	}
+
+	tb := lite.Table("Coupon")
+	if err := tb.DropIfExists(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.Migrate(ctx, new(Coupon)); err != nil {
+		t.Fatal(err)
+	}
+	key := datastore.NameKey("Coupon", "NEWYEAR", nil)
+	if err := tb.Create(ctx, &Coupon{Key: key, Code: "NEWYEAR"}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := tb.Create(ctx, &Coupon{Key: key, Code: "NEWYEAR"})
+	if !errors.Is(err, goloquent.ErrDuplicateKey) {
+		t.Fatal(fmt.Errorf("expected duplicate key error, but end up with %v", err))
+	}
+	var driverErr sqlite3.Error
+	if !errors.As(err, &driverErr) || driverErr.Code != sqlite3.ErrConstraint {
+		t.Fatal(fmt.Errorf("expected driver error is preserved, but end up with %v", err))
+	}
+
+	err = tb.Where("Code", "=", "NEWYEAR").Update(ctx, map[string]interface{}{"Discount": 10})
+	if !errors.Is(err, goloquent.ErrInvalidField) {
+		t.Fatal(fmt.Errorf("expected invalid field error, but end up with %v", err))
+	}
+}
+
+func TestSQLiteVersion(t *testing.T) {
+	type Account struct {
+		Key     *datastore.Key `goloquent:"__key__"`
+		Balance int64
+		Version uint32 `goloquent:",version"`
+	}
+
+	tb := lite.Table("Account")
+	if err := tb.DropIfExists(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.Migrate(ctx, new(Account)); err != nil {
+		t.Fatal(err)
+	}
+	acc := &Account{Balance: 100}
+	if err := tb.Create(ctx, acc); err != nil {
+		t.Fatal(err)
+	}
+
+	stale := new(Account)
+	if err := tb.Find(ctx, acc.Key, stale); err != nil {
+		t.Fatal(err)
+	}
+	acc.Balance = 50
+	if err := tb.Save(ctx, acc); err != nil {
+		t.Fatal(err)
+	}
+	if acc.Version != 1 {
+		t.Fatal(fmt.Errorf("expected version 1 after save, but end up with %d", acc.Version))
+	}
+	stale.Balance = 200
+	if err := tb.Save(ctx, stale); !errors.Is(err, goloquent.ErrStaleEntity) {
+		t.Fatal(fmt.Errorf("expected stale entity error, but end up with %v", err))
+	}
+
+	if err := tb.Where("$Key", "=", acc.Key).Update(ctx, Account{Balance: 80, Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.Where("$Key", "=", acc.Key).Update(ctx, Account{Balance: 70, Version: 1}); !errors.Is(err, goloquent.ErrStaleEntity) {
+		t.Fatal(fmt.Errorf("expected stale entity error, but end up with %v", err))
+	}
+	if err := tb.Where("Balance", "=", -1).Update(ctx, Account{Balance: 70, Version: 2}); err != nil {
+		t.Fatal(fmt.Errorf("unmatched update shouldn't be stale, %v", err))
+	}
+	// zero version is a partial update without the version check
+	if err := tb.Where("$Key", "=", acc.Key).Update(ctx, Account{Balance: 60}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.Find(ctx, acc.Key, acc); err != nil {
+		t.Fatal(err)
+	}
+	if acc.Balance != 60 || acc.Version != 3 {
+		t.Fatal(fmt.Errorf("unexpected account, %+v", acc))
+	}
+
+	missing := &Account{Key: datastore.IDKey("Account", 999999, nil), Balance: 10, Version: 1}
+	if err := tb.Save(ctx, missing); !errors.Is(err, goloquent.ErrNoSuchEntity) {
+		t.Fatal(fmt.Errorf("expected no such entity error, but end up with %v", err))
+	}
+}
+
+func TestSQLiteTimestamp(t *testing.T) {
+	type Order struct {
+		Key       *datastore.Key `goloquent:"__key__"`
+		Status    string
+		Revision  int
+		CreatedAt time.Time  `goloquent:",createdat"`
+		UpdatedAt *time.Time `goloquent:",updatedat"`
+	}
+
+	tb := lite.Table("Order")
+	if err := tb.DropIfExists(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.Migrate(ctx, new(Order)); err != nil {
+		t.Fatal(err)
+	}
+	order := &Order{Status: "PENDING"}
+	if err := tb.Create(ctx, order); err != nil {
+		t.Fatal(err)
+	}
+	if order.CreatedAt.IsZero() || order.UpdatedAt == nil {
+		t.Fatal(fmt.Errorf("expected timestamp is set on create, %+v", order))
+	}
+
+	past := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	created := order.CreatedAt
+	if err := tb.Upsert(ctx, &Order{Key: order.Key, Status: "PAID", CreatedAt: past, UpdatedAt: &past}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.Find(ctx, order.Key, order); err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != "PAID" || order.CreatedAt.Unix() != created.Unix() || !order.UpdatedAt.After(past) {
+		t.Fatal(fmt.Errorf("expected only updated timestamp is changed on conflict, %+v", order))
+	}
+
+	order.UpdatedAt = &past
+	if err := tb.Save(ctx, order); err != nil {
+		t.Fatal(err)
+	}
+	if !order.UpdatedAt.After(past) {
+		t.Fatal(fmt.Errorf("expected updated timestamp is set on save, %+v", order))
+	}
+
+	if err := tb.Where("$Key", "=", order.Key).Update(ctx, Order{Status: "SHIPPED", UpdatedAt: &past}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.Find(ctx, order.Key, order); err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != "SHIPPED" || !order.UpdatedAt.After(past) || order.CreatedAt.Unix() != created.Unix() {
+		t.Fatal(fmt.Errorf("expected updated timestamp is set on update, %+v", order))
+	}
+
+	// the map has no model, the updated timestamp is resolved from the registered model of the table
+	goloquent.RegisterModel("Order", new(Order))
+	for _, v := range []interface{}{"DELIVERED", expr.Inc("Revision", 1)} {
+		if err := tb.Where("$Key", "=", order.Key).Update(ctx, map[string]interface{}{"UpdatedAt": past}); err != nil {
+			t.Fatal(err)
+		}
+		field := "Status"
+		if _, isOk := v.(expr.Arithmetic); isOk {
+			field = "Revision"
+		}
+		if err := tb.Where("$Key", "=", order.Key).Update(ctx, map[string]interface{}{field: v}); err != nil {
+			t.Fatal(err)
+		}
+		if err := tb.Find(ctx, order.Key, order); err != nil {
+			t.Fatal(err)
+		}
+		if !order.UpdatedAt.After(past) {
+			t.Fatal(fmt.Errorf("expected updated timestamp is set on update with map, %+v", order))
+		}
+	}
+	if order.Status != "DELIVERED" || order.Revision != 1 {
+		t.Fatal(fmt.Errorf("unexpected order after update with map, %+v", order))
+	}
+}
+
+type AuditLog struct {
+	Key    *datastore.Key `goloquent:"__key__"`
+	Action string
+}
+
+type HookOrder struct {
+	Key    *datastore.Key `goloquent:"__key__"`
+	Status string
+}
+
+func (o *HookOrder) audit(ctx context.Context, db *goloquent.DB, action string) error {
+	return db.Create(ctx, &AuditLog{Action: action})
+}
+
+func (o *HookOrder) BeforeCreate(ctx context.Context, db *goloquent.DB) error {
+	if o.Status == "" {
+		return errors.New("status is required")
+	}
+	return nil
+}
+
+func (o *HookOrder) AfterCreate(ctx context.Context, db *goloquent.DB) error {
+	return o.audit(ctx, db, "create")
+}
+
+func (o *HookOrder) BeforeUpdate(ctx context.Context, db *goloquent.DB) error {
+	o.Status = "UPDATED"
+	return nil
+}
+
+func (o *HookOrder) AfterUpdate(ctx context.Context, db *goloquent.DB) error {
+	return o.audit(ctx, db, "update")
+}
+
+func (o *HookOrder) AfterDelete(ctx context.Context, db *goloquent.DB) error {
+	return o.audit(ctx, db, "delete")
+}
+
+func TestSQLiteHook(t *testing.T) {
+	for _, m := range []interface{}{new(HookOrder), new(AuditLog)} {
+		if err := lite.Table(reflect.TypeOf(m).Elem().Name()).DropIfExists(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if err := lite.Migrate(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := lite.Create(ctx, new(HookOrder)); err == nil {
+		t.Fatal("expected before create hook to reject the entity")
+	}
+
+	order := &HookOrder{Status: "PENDING"}
+	if err := lite.Create(ctx, order); err != nil {
+		t.Fatal(err)
+	}
+	if err := lite.Save(ctx, order); err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != "UPDATED" {
+		t.Fatalf("expected before update hook to mutate the entity, got %q", order.Status)
+	}
+
+	// audit row should be rollback together with the transaction
+	errRollback := errors.New("rollback")
+	if err := lite.RunInTransaction(func(txn *goloquent.DB) error {
+		if err := txn.Delete(ctx, order); err != nil {
+			return err
+		}
+		return errRollback
+	}); err != errRollback {
+		t.Fatal(err)
+	}
+
+	logs := []AuditLog{}
+	if err := lite.NewQuery().Get(ctx, &logs); err != nil {
+		t.Fatal(err)
+	}
+	actions := make(map[string]int)
+	for _, l := range logs {
+		actions[l.Action]++
+	}
+	if len(logs) != 2 || actions["create"] != 1 || actions["update"] != 1 {
+		t.Fatalf("unexpected audit logs, %v", logs)
+	}
+}
+
+type merchantKey struct{}
+
+type TenantOrder struct {
+	Key        *datastore.Key `goloquent:"__key__"`
+	MerchantID string
+	Status     string
+	Amount     int
+	Deleted    goloquent.SoftDelete
+}
+
+func (o *TenantOrder) Scopes() map[string]goloquent.ScopeFunc {
+	return map[string]goloquent.ScopeFunc{
+		"merchant": func(ctx context.Context, q *goloquent.Query) *goloquent.Query {
+			return q.WhereEqual("MerchantID", ctx.Value(merchantKey{}))
+		},
+	}
+}
+
+// the scopes are resolved from the `Scoper` of the registered model, even the query has no model
+func TestSQLiteGlobalScope(t *testing.T) {
+	tb := lite.Table("TenantOrder")
+	if err := tb.DropIfExists(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.Migrate(ctx, new(TenantOrder)); err != nil {
+		t.Fatal(err)
+	}
+	orders := []TenantOrder{
+		{MerchantID: "M1", Status: "PENDING", Amount: 10},
+		{MerchantID: "M1", Status: "PENDING", Amount: 20},
+		{MerchantID: "M2", Status: "PENDING", Amount: 30},
+	}
+	if err := lite.Create(ctx, &orders); err != nil {
+		t.Fatal(err)
+	}
+
+	// the query without model resolve the scopes from the registered model
+	goloquent.RegisterModel("TenantOrder", new(TenantOrder))
+	defer goloquent.UnregisterModel("TenantOrder")
+
+	mctx := context.WithValue(ctx, merchantKey{}, "M1")
+	result := []TenantOrder{}
+	if err := lite.NewQuery().Get(mctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("expected 2 orders of merchant M1, got %d", len(result))
+	}
+	if n, err := tb.Count(mctx); err != nil || n != 2 {
+		t.Fatalf("expected count 2 of merchant M1, got %d, %v", n, err)
+	}
+	if n, err := tb.Sum(mctx, "Amount"); err != nil || n != 30 {
+		t.Fatalf("expected sum 30 of merchant M1, got %v, %v", n, err)
+	}
+
+	if err := tb.Update(mctx, map[string]interface{}{"Status": "PAID"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := lite.NewQuery().WithoutScope().WhereEqual("Status", "PAID").Get(mctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("expected 2 paid orders, got %d", len(result))
+	}
+
+	m2ctx := context.WithValue(ctx, merchantKey{}, "M2")
+	if err := lite.Delete(m2ctx, &orders[2]); err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.OnlyTrashed().Restore(mctx); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := tb.WithoutScope().Count(ctx); err != nil || n != 2 {
+		t.Fatalf("expected soft deleted order of merchant M2 remain deleted, got %d, %v", n, err)
+	}
+
+	if err := tb.WhereNotEqual("Status", "").Unscoped().Flush(m2ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := lite.NewQuery().WithoutScope("merchant").Unscoped().Get(mctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("expected orders of merchant M2 to be deleted, got %d", len(result))
+	}
+}
+
+func TestSQLiteRegisterScope(t *testing.T) {
+	goloquent.RegisterScope("TenantOrder", "paid", func(ctx context.Context, q *goloquent.Query) *goloquent.Query {
+		return q.WhereEqual("Status", "PAID")
+	})
+	defer goloquent.UnregisterScope("TenantOrder", "paid")
+
+	tb := lite.Table("TenantOrder")
+	if err := tb.Update(context.WithValue(ctx, merchantKey{}, "M1"), map[string]interface{}{"Status": "PENDING"}); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := tb.WithoutScope("merchant").Count(ctx); err != nil || n != 0 {
+		t.Fatalf("expected no paid order, got %d, %v", n, err)
+	}
+	if n, err := tb.WithoutScope("paid", "merchant").Count(ctx); err != nil || n != 2 {
+		t.Fatalf("expected 2 orders, got %d, %v", n, err)
+	}
+}
+
+func TestSQLiteEach(t *testing.T) {
+	users := []User{}
+	if err := lite.NewQuery().Get(ctx, &users); err != nil {
+		t.Fatal(err)
+	}
+
+	count := 0
+	u := new(User)
+	if err := lite.NewQuery().Each(ctx, u, func() error {
+		if u.Key == nil {
+			return errors.New("key is not decoded")
+		}
+		count++
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if count != len(users) {
+		t.Fatalf("expected %d rows, got %d", len(users), count)
+	}
+
+	// the connection is not held exclusively by the cursor
+	count = 0
+	if err := lite.Table("User").Each(ctx, u, func() error {
+		found := new(User)
+		if err := lite.Find(ctx, u.Key, found); err != nil {
+			return err
+		}
+		count++
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if count != len(users) {
+		t.Fatalf("expected %d rows, got %d", len(users), count)
+	}
+	if n, err := lite.Table("User").Count(ctx); err != nil || int(n) != len(users) {
+		t.Fatalf("expected %d users after each, got %d, %v", len(users), n, err)
+	}
+
+	errStop := errors.New("stop")
+	if err := lite.NewQuery().Each(ctx, u, func() error {
+		return errStop
+	}); err != errStop {
+		t.Fatalf("expected iteration to be stopped, got %v", err)
+	}
+
+	rows, err := lite.Table("User").Rows(ctx, u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	count = 0
+	for rows.Next() {
+		if err := rows.Scan(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if count != len(users) {
+		t.Fatalf("expected %d rows, got %d", len(users), count)
+	}
+}
+
+func TestSQLiteChunk(t *testing.T) {
+	users := []User{}
+	if err := lite.NewQuery().Get(ctx, &users); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) < 3 {
+		t.Skip("not enough records to chunk")
+	}
+
+	seen := make(map[string]bool)
+	chunk := []User{}
+	if err := lite.NewQuery().Chunk(ctx, 2, &chunk, func() error {
+		if len(chunk) > 2 {
+			return fmt.Errorf("chunk size exceeded, %d", len(chunk))
+		}
+		for _, u := range chunk {
+			if seen[u.Key.String()] {
+				return fmt.Errorf("duplicate key %v", u.Key)
+			}
+			seen[u.Key.String()] = true
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != len(users) {
+		t.Fatalf("expected %d records, got %d", len(users), len(seen))
+	}
+
+	// interrupt after the first chunk and resume from the checkpoint
+	cp := new(goloquent.Checkpoint)
+	errStop := errors.New("stop")
+	count, calls := 0, 0
+	if err := lite.NewQuery().ChunkFrom(ctx, cp, 2, &chunk, func() error {
+		calls++
+		if calls > 1 {
+			return errStop
+		}
+		count += len(chunk)
+		return nil
+	}); err != errStop {
+		t.Fatalf("expected chunk to be interrupted, got %v", err)
+	}
+	if cp.Key == nil {
+		t.Fatal("expected checkpoint to be recorded")
+	}
+	if err := lite.Table("User").ChunkFrom(ctx, cp, 2, &chunk, func() error {
+		count += len(chunk)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if count != len(users) {
+		t.Fatalf("expected %d records after resume, got %d", len(users), count)
+	}
+}
+
+func TestSQLiteBulkCreate(t *testing.T) {
+	type Event struct {
+		Key     *datastore.Key `goloquent:"__key__"`
+		Name    string
+		Payload string
+	}
+
+	tb := lite.Table("Event")
+	if err := tb.DropIfExists(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.Migrate(ctx, new(Event)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the rows exceed both the batch size and the bind parameters limit of sqlite
+	events := make([]Event, 1500)
+	for i := range events {
+		events[i] = Event{Name: fmt.Sprintf("event-%d", i), Payload: "{}"}
+	}
+	if err := lite.Create(ctx, &events); err != nil {
+		t.Fatal(err)
+	}
+	for i := range events {
+		events[i].Payload = "[]"
+	}
+	if err := lite.Upsert(ctx, &events); err != nil {
+		t.Fatal(err)
+	}
+
+	result := []Event{}
+	if err := lite.NewQuery().WhereEqual("Payload", "[]").Get(ctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != len(events) {
+		t.Fatalf("expected %d events, got %d", len(events), len(result))
+	}
+}
+
+func TestSQLiteAggregate(t *testing.T) {
+	type Score struct {
+		Key     *datastore.Key `goloquent:"__key__"`
+		Player  string
+		Point   int
+		Detail  json.RawMessage
+		Deleted goloquent.SoftDelete
+	}
+
+	tb := lite.Table("Score")
+	if err := tb.DropIfExists(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.Migrate(ctx, new(Score)); err != nil {
+		t.Fatal(err)
+	}
+	parent := datastore.NameKey("Player", "p1", nil)
+	scores := []Score{
+		{Player: "p1", Point: 10, Detail: json.RawMessage(`{"bonus":1}`)},
+		{Player: "p1", Point: 20, Detail: json.RawMessage(`{"bonus":2}`)},
+		{Player: "p1", Point: 30, Detail: json.RawMessage(`{"bonus":3}`)},
+	}
+	if err := lite.Create(ctx, &scores, parent); err != nil {
+		t.Fatal(err)
+	}
+	if err := lite.Create(ctx, &Score{Player: "p2", Point: 100, Detail: json.RawMessage(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := lite.Delete(ctx, &scores[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := tb.Count(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Fatalf("expected 3 records without soft deleted, got %d", count)
+	}
+	if count, err = tb.Unscoped().Count(ctx); err != nil || count != 4 {
+		t.Fatalf("expected 4 records, got %d, %v", count, err)
+	}
+
+	q := lite.Table("Score").Ancestor(parent)
+	if n, err := q.Sum(ctx, "Point"); err != nil || n != 30 {
+		t.Fatalf("expected sum 30, got %v, %v", n, err)
+	}
+	if n, err := q.Avg(ctx, "Point"); err != nil || n != 15 {
+		t.Fatalf("expected avg 15, got %v, %v", n, err)
+	}
+	if n, err := q.Max(ctx, "Detail>bonus"); err != nil || n != 2 {
+		t.Fatalf("expected max bonus 2, got %v, %v", n, err)
+	}
+	if n, err := tb.Min(ctx, "Point"); err != nil || n != 10 {
+		t.Fatalf("expected min 10, got %v, %v", n, err)
+	}
+	if n, err := tb.Where("Point", ">", 1000).Sum(ctx, "Point"); err != nil || n != 0 {
+		t.Fatalf("expected sum 0 without record, got %v, %v", n, err)
+	}
+	var player string
+	if err := tb.SelectAggregate(expr.Max("Player")).Scan(ctx, &player); err != nil || player != "p2" {
+		t.Fatalf("expected max player p2, got %q, %v", player, err)
+	}
+	if _, err := tb.GroupBy("Player").Count(ctx); err == nil {
+		t.Fatal("expected grouped query is rejected by aggregation")
+	}
+
+	type Ledger struct {
+		Key    *datastore.Key `goloquent:"__key__"`
+		Amount int
+	}
+	lg := lite.Table("Ledger")
+	if err := lg.DropIfExists(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := lg.Migrate(ctx, new(Ledger)); err != nil {
+		t.Fatal(err)
+	}
+	if err := lg.Create(ctx, &[]Ledger{{Amount: 1}, {Amount: 2}}); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := lg.Count(ctx); err != nil || count != 2 {
+		t.Fatalf("expected 2 ledgers, got %d, %v", count, err)
+	}
+	{
+		// the soft delete column is added by migration
+		type Ledger struct {
+			Key     *datastore.Key `goloquent:"__key__"`
+			Amount  int
+			Deleted goloquent.SoftDelete
+		}
+		if err := lg.Migrate(ctx, new(Ledger)); err != nil {
+			t.Fatal(err)
+		}
+		ledger := new(Ledger)
+		if err := lg.First(ctx, ledger); err != nil {
+			t.Fatal(err)
+		}
+		if err := lite.Delete(ctx, ledger); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if count, err := lg.Count(ctx); err != nil || count != 1 {
+		t.Fatalf("expected 1 ledger after migration, got %d, %v", count, err)
+	}
+}
+
+func TestSQLiteGroupBy(t *testing.T) {
+	type Sale struct {
+		Key     *datastore.Key `goloquent:"__key__"`
+		Country string
+		Amount  float64
+	}
+	type SaleReport struct {
+		Country string
+		Count   int64
+		Total   float64
+	}
+
+	tb := lite.Table("Sale")
+	if err := tb.DropIfExists(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.Migrate(ctx, new(Sale)); err != nil {
+		t.Fatal(err)
+	}
+	sales := []Sale{
+		{Country: "MY", Amount: 10},
+		{Country: "MY", Amount: 20},
+		{Country: "SG", Amount: 5},
+		{Country: "TH", Amount: 1},
+		{Country: "TH", Amount: 2},
+		{Country: "TH", Amount: 3},
+	}
+	if err := lite.Create(ctx, &sales); err != nil {
+		t.Fatal(err)
+	}
+
+	reports := []SaleReport{}
+	if err := tb.Select("Country").
+		SelectAggregate(expr.Count("*"), expr.Sum("Amount").As("Total")).
+		GroupBy("Country").
+		Having("Count", ">", 1).
+		OrderBy("Country").
+		Get(ctx, &reports); err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 2 {
+		t.Fatalf("expected 2 groups, got %v", reports)
+	}
+	if reports[0].Country != "MY" || reports[0].Count != 2 || reports[0].Total != 30 {
+		t.Fatalf("unexpected report %v", reports[0])
+	}
+	if reports[1].Country != "TH" || reports[1].Count != 3 || reports[1].Total != 6 {
+		t.Fatalf("unexpected report %v", reports[1])
+	}
+
+	var country string
+	var total float64
+	if err := tb.Select("Country").
+		SelectAggregate(expr.Sum("Amount")).
+		GroupBy("Country").
+		Having(expr.Max("Amount"), "<", 10).
+		OrderBy("-Country").
+		Limit(1).
+		Scan(ctx, &country, &total); err != nil {
+		t.Fatal(err)
+	}
+	if country != "TH" || total != 6 {
+		t.Fatalf("unexpected scan result %s, %v", country, total)
+	}
+}
+
+func TestSQLiteWhereAny(t *testing.T) {
+	type Payment struct {
+		Key    *datastore.Key `goloquent:"__key__"`
+		Status string
+		Amount int
+		Meta   json.RawMessage
+	}
+
+	tb := lite.Table("Payment")
+	if err := tb.DropIfExists(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.Migrate(ctx, new(Payment)); err != nil {
+		t.Fatal(err)
+	}
+	payments := []Payment{
+		{Status: "A", Amount: 5, Meta: json.RawMessage(`{"channel":"web"}`)},
+		{Status: "A", Amount: 20, Meta: json.RawMessage(`{"channel":"app"}`)},
+		{Status: "B", Amount: 30, Meta: json.RawMessage(`{"channel":"web"}`)},
+		{Status: "C", Amount: 40, Meta: json.RawMessage(`{"channel":"pos"}`)},
+	}
+	if err := lite.Create(ctx, &payments); err != nil {
+		t.Fatal(err)
+	}
+
+	result := []Payment{}
+	// (Status = 'A' OR Status = 'B') AND Amount > 10
+	if err := tb.WhereAny(func(q *goloquent.Query) *goloquent.Query {
+		return q.WhereEqual("Status", "A").WhereEqual("Status", "B")
+	}).Where("Amount", ">", 10).Get(ctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("expected 2 payments, got %d", len(result))
+	}
+
+	// Amount > 10 AND (Status = 'C' OR Meta.channel = 'app')
+	if err := tb.Where("Amount", ">", 10).
+		WhereEqual("Status", "C").
+		OrWhereJSON("Meta>channel", "=", "app").
+		Get(ctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("expected 2 payments, got %d", len(result))
+	}
+
+	// Status = 'C' OR (Status = 'A' AND Meta.channel = 'web')
+	if err := tb.WhereAny(func(q *goloquent.Query) *goloquent.Query {
+		return q.WhereEqual("Status", "C").WhereAll(func(q *goloquent.Query) *goloquent.Query {
+			return q.WhereEqual("Status", "A").WhereJSONEqual("Meta>channel", "web")
+		})
+	}).Get(ctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("expected 2 payments, got %d", len(result))
+	}
+}
+
+func TestSQLiteJoin(t *testing.T) {
+	type Buyer struct {
+		Key     *datastore.Key `goloquent:"__key__"`
+		Name    string
+		Deleted goloquent.SoftDelete
+	}
+	type Purchase struct {
+		Key      *datastore.Key `goloquent:"__key__"`
+		BuyerKey *datastore.Key
+		Amount   int
+	}
+
+	for _, m := range []interface{}{new(Buyer), new(Purchase)} {
+		tb := lite.Table(reflect.TypeOf(m).Elem().Name())
+		if err := tb.DropIfExists(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if err := tb.Migrate(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buyers := []Buyer{{Name: "b1"}, {Name: "b3"}}
+	if err := lite.Create(ctx, &buyers); err != nil {
+		t.Fatal(err)
+	}
+	child := &Buyer{Name: "b2"}
+	if err := lite.Create(ctx, child, datastore.NameKey("Merchant", "m1", nil)); err != nil {
+		t.Fatal(err)
+	}
+	purchases := []Purchase{
+		{BuyerKey: buyers[0].Key, Amount: 10},
+		{BuyerKey: buyers[0].Key, Amount: 20},
+		{BuyerKey: child.Key, Amount: 30},
+		{BuyerKey: datastore.IDKey("Buyer", 404, nil), Amount: 40},
+		{BuyerKey: buyers[1].Key, Amount: 50},
+	}
+	if err := lite.Create(ctx, &purchases); err != nil {
+		t.Fatal(err)
+	}
+	if err := lite.Delete(ctx, &buyers[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	type Result struct {
+		Purchase Purchase
+		Buyer    Buyer
+	}
+	result := []Result{}
+	if err := lite.Table("Purchase").
+		Join("Buyer", "BuyerKey", "=", "__key__").
+		OrderBy("Amount").
+		Get(ctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 3 {
+		t.Fatalf("expected 3 purchases, got %d", len(result))
+	}
+	if result[2].Buyer.Name != "b2" || !result[2].Buyer.Key.Equal(child.Key) {
+		t.Fatalf("unexpected buyer %v of purchase %v", result[2].Buyer.Key, result[2].Purchase.Key)
+	}
+
+	if err := lite.Table("Purchase").
+		Join("Buyer", "BuyerKey", "=", "__key__").
+		WhereEqual("Buyer.Name", "b1").
+		Get(ctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("expected 2 purchases of b1, got %d", len(result))
+	}
+
+	optional := []struct {
+		Purchase Purchase
+		Buyer    *Buyer
+	}{}
+	if err := lite.Table("Purchase").
+		LeftJoin("Buyer", "Purchase.BuyerKey", "=", "Buyer.$Key").
+		OrderBy("-Amount").
+		Get(ctx, &optional); err != nil {
+		t.Fatal(err)
+	}
+	if len(optional) != 5 {
+		t.Fatalf("expected 5 purchases, got %d", len(optional))
+	}
+	// the buyer of 50 is soft deleted and the buyer of 40 is not exists
+	if optional[0].Buyer != nil || optional[1].Buyer != nil || optional[2].Buyer == nil {
+		t.Fatal("unexpected buyer of left join")
+	}
+
+	first := Result{}
+	if err := lite.Table("Purchase").
+		Join("Buyer", "BuyerKey", "=", "__key__").
+		Where("Amount", ">", 10).
+		OrderBy("Amount").
+		First(ctx, &first); err != nil {
+		t.Fatal(err)
+	}
+	if first.Purchase.Amount != 20 || first.Buyer.Name != "b1" {
+		t.Fatalf("unexpected first result %v", first)
+	}
+}
+
+type TenantItem struct {
+	Key        *datastore.Key `goloquent:"__key__"`
+	MerchantID string
+	Detail     json.RawMessage
+}
+
+func (i *TenantItem) Scopes() map[string]goloquent.ScopeFunc {
+	return map[string]goloquent.ScopeFunc{
+		"merchant": func(ctx context.Context, q *goloquent.Query) *goloquent.Query {
+			return q.WhereEqual("MerchantID", ctx.Value(merchantKey{}))
+		},
+	}
+}
+
+// the scopes of joined table are applied on the join, the json filter is qualified by table
+func TestSQLiteJoinScope(t *testing.T) {
+	type Cart struct {
+		Key     *datastore.Key `goloquent:"__key__"`
+		ItemKey *datastore.Key
+		Detail  json.RawMessage
+	}
+
+	for _, m := range []interface{}{new(Cart), new(TenantItem)} {
+		tb := lite.Table(reflect.TypeOf(m).Elem().Name())
+		if err := tb.DropIfExists(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if err := tb.Migrate(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	items := []TenantItem{
+		{MerchantID: "M1", Detail: json.RawMessage(`{"color":"red"}`)},
+		{MerchantID: "M1", Detail: json.RawMessage(`{"color":"blue"}`)},
+		{MerchantID: "M2", Detail: json.RawMessage(`{"color":"red"}`)},
+	}
+	if err := lite.Create(ctx, &items); err != nil {
+		t.Fatal(err)
+	}
+	carts := []Cart{
+		{ItemKey: items[0].Key, Detail: json.RawMessage(`{"color":"blue"}`)},
+		{ItemKey: items[1].Key, Detail: json.RawMessage(`{"color":"blue"}`)},
+		{ItemKey: items[2].Key, Detail: json.RawMessage(`{"color":"red"}`)},
+	}
+	if err := lite.Create(ctx, &carts); err != nil {
+		t.Fatal(err)
+	}
+
+	type Result struct {
+		Cart       Cart
+		TenantItem *TenantItem
+	}
+	mctx := context.WithValue(ctx, merchantKey{}, "M1")
+	result := []Result{}
+	if err := lite.Table("Cart").
+		Join("TenantItem", "ItemKey", "=", "__key__").
+		Get(mctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("expected 2 carts of merchant M1, got %d", len(result))
+	}
+	for _, r := range result {
+		if r.TenantItem.MerchantID != "M1" {
+			t.Fatalf("unexpected item of merchant %q", r.TenantItem.MerchantID)
+		}
+	}
+
+	if err := lite.Table("Cart").
+		LeftJoin("TenantItem", "ItemKey", "=", "__key__").
+		WhereJSON("TenantItem.Detail>color", "=", "red").
+		Get(mctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 1 || !result[0].Cart.Key.Equal(carts[0].Key) {
+		t.Fatalf("expected cart of red item, got %v", result)
+	}
+
+	if err := lite.Table("Cart").
+		LeftJoin("TenantItem", "ItemKey", "=", "__key__").
+		WhereJSON("Detail>color", "=", "red").
+		Get(mctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	// the item of merchant M2 is excluded from the join
+	if len(result) != 1 || !result[0].Cart.Key.Equal(carts[2].Key) || result[0].TenantItem != nil {
+		t.Fatalf("expected red cart without item, got %v", result)
+	}
+}
+
+func TestSQLiteExpr(t *testing.T) {
+	type Wallet struct {
+		Key     *datastore.Key `goloquent:"__key__"`
+		Name    string
+		Nick    string
+		Balance int
+		Credit  int
+	}
+
+	tb := lite.Table("Wallet")
+	if err := tb.DropIfExists(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.Migrate(ctx, new(Wallet)); err != nil {
+		t.Fatal(err)
+	}
+	wallets := []Wallet{
+		{Name: "a", Balance: 10, Credit: 5},
+		{Name: "b", Balance: 0, Credit: 5},
+		{Name: "c", Balance: 20, Credit: 30},
+	}
+	if err := lite.Create(ctx, &wallets); err != nil {
+		t.Fatal(err)
+	}
+
+	result := []Wallet{}
+	if err := tb.Where("Balance", ">", expr.Col("Credit")).Get(ctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 1 || result[0].Name != "a" {
+		t.Fatalf("expected wallet a, got %v", result)
+	}
+
+	if err := tb.Update(ctx, map[string]interface{}{
+		"Balance": expr.Inc("Balance", 10),
+		"Nick":    expr.Coalesce(expr.Raw("NULL"), expr.Col("Name")),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.Where("Balance", ">", expr.Col("Credit")).Get(ctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("expected 2 wallets, got %d", len(result))
+	}
+	for _, w := range result {
+		if w.Nick != w.Name {
+			t.Fatalf("expected nick %q, got %q", w.Name, w.Nick)
+		}
+	}
+
+	if err := tb.Where("Balance", "=", expr.Raw("? * ?", 15, 2)).Get(ctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 1 || result[0].Name != "c" {
+		t.Fatalf("expected wallet c, got %v", result)
+	}
+	if err := tb.Where("Credit", "<=>", expr.Col("Balance")).Get(ctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 1 || result[0].Name != "c" {
+		t.Fatalf("expected wallet c, got %v", result)
+	}
+	if err := tb.Where("Credit", "<=>", 30).Get(ctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 1 || result[0].Name != "c" {
+		t.Fatalf("expected wallet c, got %v", result)
+	}
+
+	// the value which is not in the list come first
+	if err := tb.OrderBy(expr.Field("Name", []string{"c", "a"})).Get(ctx, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 3 || result[0].Name != "b" || result[1].Name != "c" || result[2].Name != "a" {
+		t.Fatalf("unexpected ordering %v", result)
+	}
+}
+
+func TestSQLiteScan(t *testing.T) {
+	var count, sum uint
+	if err := lite.Table("User").
+		Select("COALESCE(COUNT(*),0), COALESCE(SUM(Age),0)").
+		Scan(ctx, &count, &sum); err != nil {
+		t.Fatal(err)
+	}
+	log.Println("Count :", count, ", Sum :", sum)
+}
+
+func TestSQLiteClose(t *testing.T) {
+	defer lite.Close()
+}
@@ -0,0 +1,85 @@
+package goloquent
+
+import (
+	"reflect"
+	"time"
+)
+
+// timestampFields : the fields tagged with `createdat` and `updatedat`, it's nil when the field is not declared
+func (sc *StructCodec) timestampFields() (created *field, updated *field) {
+	for i, f := range sc.fields {
+		switch {
+		case f.IsCreatedAt():
+			created = &sc.fields[i]
+		case f.IsUpdatedAt():
+			updated = &sc.fields[i]
+		}
+	}
+	return
+}
+
+func isTimestampType(t reflect.Type) bool {
+	return t == typeOfTime || t == reflect.PtrTo(typeOfTime)
+}
+
+// setTimestamp will set the time when it's zero or forced
+func setTimestamp(v reflect.Value, now time.Time, isForce bool) {
+	if !v.IsValid() || !v.CanSet() {
+		return
+	}
+	switch vi := v.Interface().(type) {
+	case time.Time:
+		if isForce || vi.IsZero() {
+			v.Set(reflect.ValueOf(now))
+		}
+	case *time.Time:
+		if isForce || vi == nil || vi.IsZero() {
+			t := now
+			v.Set(reflect.ValueOf(&t))
+		}
+	}
+}
+
+// touchTimestamps will set the created timestamp on create when it's zero,
+// and the updated timestamp on update or on create when it's zero
+func touchTimestamps(v reflect.Value, sc *StructCodec, isCreate, isUpdate bool) {
+	created, updated := sc.timestampFields()
+	if created == nil && updated == nil {
+		return
+	}
+	v = reflect.Indirect(v)
+	now := time.Now().UTC()
+	if created != nil && isCreate {
+		setTimestamp(relationField(v, created.paths), now, false)
+	}
+	if updated != nil && (isCreate || isUpdate) {
+		setTimestamp(relationField(v, updated.paths), now, isUpdate)
+	}
+}
+
+// touchMapTimestamp will copy the map with the updated timestamp of the model registered by `RegisterModel`,
+// the map is unchanged when the timestamp is present or the model of the table is not registered
+func touchMapTimestamp(table string, v reflect.Value) (reflect.Value, error) {
+	t, mt := modelOf(table), v.Type()
+	if t == nil || mt.Key().Kind() != reflect.String || !typeOfTime.AssignableTo(mt.Elem()) {
+		return v, nil
+	}
+	codec, err := getStructCodec(reflect.New(t).Interface())
+	if err != nil {
+		return v, err
+	}
+	_, updated := codec.timestampFields()
+	if updated == nil {
+		return v, nil
+	}
+	name := reflect.ValueOf(updated.name).Convert(mt.Key())
+	if v.MapIndex(name).IsValid() {
+		return v, nil
+	}
+	vv := reflect.MakeMapWithSize(mt, v.Len()+1)
+	for _, k := range v.MapKeys() {
+		vv.SetMapIndex(k, v.MapIndex(k))
+	}
+	vv.SetMapIndex(name, reflect.ValueOf(time.Now().UTC()))
+	return vv, nil
+}
@@ -0,0 +1,42 @@
+package goloquent
+
+import (
+	"reflect"
+)
+
+// versionField : the field tagged with `version` for optimistic concurrency control,
+// it return nil when the entity is not versioned
+func (sc *StructCodec) versionField() *field {
+	for _, f := range sc.fields {
+		if f.IsVersion() {
+			return &f
+		}
+	}
+	return nil
+}
+
+func isVersionType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func getVersion(v reflect.Value) int64 {
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint())
+	}
+	return v.Int()
+}
+
+func setVersion(v reflect.Value, n int64) {
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(n))
+	default:
+		v.SetInt(n)
+	}
+}